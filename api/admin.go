@@ -0,0 +1,21 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+)
+
+// requireAdmin checks the ADMIN_KEY sent as "Authorization: ApiKey <key>"
+// and answers 401 when it's missing or wrong. With no key configured every
+// admin endpoint stays closed.
+func (cfg *Apiconfig) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	apiKey, err := auth.GetApiKey(r.Header)
+	if err != nil || cfg.AdminKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.AdminKey)) != 1 {
+		utils.RespondWithError(w, http.StatusUnauthorized, "admin access only")
+		return false
+	}
+	return true
+}
@@ -1,41 +1,152 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"sort"
+	"slices"
 	"time"
 
-	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/entitlements"
 	"github.com/Israel-Andrade-P/Chirpy.git/utils"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type (
 	chirpRequest struct {
-		Body string `json:"body"`
+		Body       string        `json:"body"`
+		ParentID   uuid.NullUUID `json:"parent_id"`
+		RepostOfID uuid.NullUUID `json:"repost_of_id"`
+		MediaIDs   []uuid.UUID   `json:"media_ids"`
 	}
 	chirpResponse struct {
+		ID           uuid.UUID         `json:"id"`
+		Body         string            `json:"body"`
+		CreatedAt    time.Time         `json:"created_at"`
+		UpdatedAt    time.Time         `json:"updated_at"`
+		UserID       uuid.UUID         `json:"user_id"`
+		Edited       bool              `json:"edited"`
+		ParentID     uuid.NullUUID     `json:"parent_id"`
+		LikeCount    int64             `json:"like_count"`
+		LikedByMe    bool              `json:"liked_by_me"`
+		RepostOfID   uuid.NullUUID     `json:"repost_of_id"`
+		RepostOf     *repostResponse   `json:"repost_of,omitempty"`
+		RechirpCount int64             `json:"rechirp_count"`
+		QuoteCount   int64             `json:"quote_count"`
+		Mentions     []mentionResponse `json:"mentions"`
+		Media        []mediaResponse   `json:"media"`
+	}
+	chirpRevisionResponse struct {
 		ID        uuid.UUID `json:"id"`
+		ChirpID   uuid.UUID `json:"chirp_id"`
 		Body      string    `json:"body"`
 		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		UserID    uuid.UUID `json:"user_id"`
+	}
+	chirpsPage struct {
+		Chirps     []chirpResponse `json:"chirps"`
+		Limit      int32           `json:"limit"`
+		NextCursor *string         `json:"next_cursor"`
 	}
 )
 
+func newChirpResponse(chirp database.Chirp) chirpResponse {
+	return chirpResponse{ID: chirp.ID, Body: chirp.Body, CreatedAt: chirp.CreatedAt, UpdatedAt: chirp.UpdatedAt, UserID: chirp.UserID, Edited: chirp.EditedAt.Valid, ParentID: chirp.ParentID, RepostOfID: chirp.RepostOfID}
+}
+
+// decorateChirps fills in everything a chirp response carries that doesn't
+// live on the chirp row itself.
+func (cfg *Apiconfig) decorateChirps(ctx context.Context, viewer uuid.NullUUID, chirps []chirpResponse) error {
+	if err := cfg.attachLikeStats(ctx, viewer, chirps); err != nil {
+		return err
+	}
+	if err := cfg.attachMentions(ctx, chirps); err != nil {
+		return err
+	}
+	if err := cfg.attachMedia(ctx, chirps); err != nil {
+		return err
+	}
+	return cfg.attachReposts(ctx, chirps)
+}
+
+// indexChirpEntities stores the hashtags and mentions found in a chirp body
+// and returns the mentioned users.
+func indexChirpEntities(ctx context.Context, q *database.Queries, chirpId uuid.UUID, body string) ([]uuid.UUID, error) {
+	if err := linkHashtags(ctx, q, chirpId, body); err != nil {
+		return nil, err
+	}
+	return linkMentions(ctx, q, chirpId, body)
+}
+
+// clearChirpEntities drops what indexChirpEntities stored, ahead of a
+// tombstone.
+func clearChirpEntities(ctx context.Context, q *database.Queries, chirpId uuid.UUID) error {
+	if err := q.ClearChirpHashtags(ctx, chirpId); err != nil {
+		return err
+	}
+	return q.ClearChirpMentions(ctx, chirpId)
+}
+
+// unlinkStaleEntities prepares an edited chirp for indexChirpEntities. Mentions
+// are dropped outright, but hashtags the new body still uses stay linked so
+// the edit doesn't count them again in the trends.
+func unlinkStaleEntities(ctx context.Context, q *database.Queries, chirpId uuid.UUID, body string) error {
+	tags := utils.ExtractHashtags(body)
+	if tags == nil {
+		tags = []string{}
+	}
+	if err := q.PruneChirpHashtags(ctx, database.PruneChirpHashtagsParams{ChirpID: chirpId, Tags: tags}); err != nil {
+		return err
+	}
+	return q.ClearChirpMentions(ctx, chirpId)
+}
+
+// validateChirpBody enforces the rules every chirp body has to pass, both on
+// creation and on edit, and returns the cleaned up message. How long it may
+// be depends on the author's plan.
+func validateChirpBody(body string, limits entitlements.Limits) (string, error) {
+	if len(body) > limits.MaxChirpLength {
+		return "", errors.New("Chirp is too long.")
+	}
+	return utils.CleanUpMessage(body), nil
+}
+
+// newChirpsPage trims the extra look-ahead row fetched by the list queries
+// and turns it into the cursor for the next page.
+func newChirpsPage(chirps []database.Chirp, limit int32) chirpsPage {
+	page := chirpsPage{Chirps: make([]chirpResponse, 0, len(chirps)), Limit: limit}
+	if len(chirps) > int(limit) {
+		chirps = chirps[:limit]
+		last := chirps[len(chirps)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		page.NextCursor = &cursor
+	}
+	for _, chirp := range chirps {
+		page.Chirps = append(page.Chirps, newChirpResponse(chirp))
+	}
+	return page
+}
+
 func (cfg *Apiconfig) SaveChirp(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Please autenticate yourself")
+	userId, ok := cfg.authenticate(w, r, scopeChirpsWrite)
+	if !ok {
 		return
 	}
-	userId, err := auth.ValidateJWT(token, cfg.Secret)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Your token is invalid, get a new one")
-		return
+	if cfg.RequireVerifiedEmail {
+		user, err := cfg.DbQueries.GetUserById(r.Context(), userId)
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		if !cfg.requireVerified(w, user) {
+			return
+		}
 	}
 	var chirpReq chirpRequest
 	if err := json.NewDecoder(r.Body).Decode(&chirpReq); err != nil {
@@ -43,59 +154,165 @@ func (cfg *Apiconfig) SaveChirp(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
-	if len(chirpReq.Body) > 140 {
-		utils.RespondWithError(w, http.StatusBadRequest, "Chirp is too long.")
+	limits, err := cfg.limitsFor(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+	cleanMessage, err := validateChirpBody(chirpReq.Body, limits)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if chirpReq.ParentID.Valid {
+		parent, err := cfg.DbQueries.GetChirp(r.Context(), chirpReq.ParentID.UUID)
+		if err != nil || parent.DeletedAt.Valid {
+			utils.RespondWithError(w, http.StatusBadRequest, "parent chirp doesn't exist")
+			return
+		}
+	}
+	if chirpReq.RepostOfID.Valid {
+		original, err := cfg.DbQueries.GetChirp(r.Context(), chirpReq.RepostOfID.UUID)
+		if err != nil || original.DeletedAt.Valid {
+			utils.RespondWithError(w, http.StatusBadRequest, "original chirp doesn't exist")
+			return
+		}
+		// Rechirping a plain rechirp amplifies the chirp it points to.
+		if isPlainRechirp(original) {
+			chirpReq.RepostOfID = original.RepostOfID
+		}
+		if cleanMessage == "" && chirpReq.ParentID.Valid {
+			utils.RespondWithError(w, http.StatusBadRequest, "a rechirp can't be a reply")
+			return
+		}
+		if cleanMessage == "" && len(chirpReq.MediaIDs) > 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "a rechirp can't have media")
+			return
+		}
+	}
+	if len(chirpReq.MediaIDs) > limits.MaxMediaPerChirp {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("a chirp can have up to %d media", limits.MaxMediaPerChirp))
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	chirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:       cleanMessage,
+		UserID:     userId,
+		ParentID:   chirpReq.ParentID,
+		RepostOfID: chirpReq.RepostOfID,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		utils.RespondWithError(w, http.StatusConflict, "you already rechirped that")
 		return
 	}
-	cleanMessage := utils.CleanUpMessage(chirpReq.Body)
-	chirp, err := cfg.DbQueries.CreateChirp(r.Context(), database.CreateChirpParams{Body: cleanMessage, UserID: userId})
 	if err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Chirp")
 		return
 	}
-	c := chirpResponse{ID: chirp.ID, Body: chirp.Body, CreatedAt: chirp.CreatedAt, UpdatedAt: chirp.UpdatedAt, UserID: chirp.UserID}
-	utils.RespondWithJson(w, http.StatusCreated, c)
+	mentioned, err := indexChirpEntities(r.Context(), qtx, chirp.ID, chirp.Body)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := attachChirpMedia(r.Context(), qtx, userId, chirp.ID, chirpReq.MediaIDs); err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := enqueueWebhookEvent(r.Context(), qtx, userId, eventChirpCreated, newWebhookChirp(chirp)); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := announceMentions(r.Context(), qtx, chirp, mentioned); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	chirpRes := []chirpResponse{newChirpResponse(chirp)}
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, chirpRes); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusCreated, chirpRes[0])
 }
 
 func (cfg *Apiconfig) GetChirps(w http.ResponseWriter, r *http.Request) {
 	authorId := r.URL.Query().Get("author_id")
 	sortParam := r.URL.Query().Get("sort")
+	if sortParam == "" {
+		sortParam = "asc"
+	}
+	if sortParam != "asc" && sortParam != "desc" {
+		utils.RespondWithError(w, http.StatusBadRequest, "sort must be asc or desc")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	chirpsRes := make([]chirpResponse, 0)
+	var author uuid.NullUUID
 	if authorId != "" {
 		userId, err := uuid.Parse(authorId)
 		if err != nil {
 			utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
 			return
 		}
-		chirps, err := cfg.DbQueries.GetChirpsByUser(r.Context(), userId)
-		if err != nil {
-			utils.RespondWithError(w, http.StatusNotFound, "no chirps")
-			return
-		}
-		for _, chirp := range chirps {
-			chirpsRes = append(chirpsRes, chirpResponse(chirp))
-		}
-		if sortParam == "desc" {
-			sort.Slice(chirpsRes, func(i, j int) bool {
-				return chirpsRes[i].CreatedAt.After(chirpsRes[j].CreatedAt)
-			})
-		}
-		utils.RespondWithJson(w, http.StatusOK, chirpsRes)
-		return
-	}
-	chirps, err := cfg.DbQueries.GetAllChirps(r.Context())
-	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "No Chirps")
-	}
-	for _, chirp := range chirps {
-		chirpsRes = append(chirpsRes, chirpResponse(chirp))
+		author = uuid.NullUUID{UUID: userId, Valid: true}
 	}
+
+	// Fetch one extra row so we know whether there is a next page.
+	var chirps []database.Chirp
 	if sortParam == "desc" {
-		sort.Slice(chirpsRes, func(i, j int) bool {
-			return chirpsRes[i].CreatedAt.After(chirpsRes[j].CreatedAt)
+		chirps, err = cfg.DbQueries.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AuthorID:        author,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			Limit:           page.Limit + 1,
+		})
+	} else {
+		chirps, err = cfg.DbQueries.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			AuthorID:        author,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			Limit:           page.Limit + 1,
 		})
 	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	chirpsRes := newChirpsPage(chirps, page.Limit)
+	if err := cfg.decorateChirps(r.Context(), cfg.viewerId(r), chirpsRes.Chirps); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
 	utils.RespondWithJson(w, http.StatusOK, chirpsRes)
 }
 
@@ -111,19 +328,22 @@ func (cfg *Apiconfig) GetChirp(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusInternalServerError, "DB Error")
 		return
 	}
-	chirpRes := chirpResponse{ID: chirp.ID, Body: chirp.Body, CreatedAt: chirp.CreatedAt, UpdatedAt: chirp.UpdatedAt, UserID: chirp.UserID}
-	utils.RespondWithJson(w, http.StatusOK, chirpRes)
+	if chirp.DeletedAt.Valid {
+		utils.RespondWithError(w, http.StatusNotFound, "chirp doesn't exist")
+		return
+	}
+	chirpRes := []chirpResponse{newChirpResponse(chirp)}
+	if err := cfg.decorateChirps(r.Context(), cfg.viewerId(r), chirpRes); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, chirpRes[0])
 }
 
 func (cfg *Apiconfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Please autenticate yourself")
-		return
-	}
-	userId, err := auth.ValidateJWT(token, cfg.Secret)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "your token is invalid, get a new one")
+	userId, ok := cfg.authenticate(w, r, scopeChirpsWrite)
+	if !ok {
 		return
 	}
 	id := r.PathValue("chirpID")
@@ -133,7 +353,7 @@ func (cfg *Apiconfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	chirp, err := cfg.DbQueries.GetChirp(r.Context(), parsedId)
-	if err != nil {
+	if err != nil || chirp.DeletedAt.Valid {
 		utils.RespondWithError(w, http.StatusBadRequest, "chirp doesn't exist")
 		return
 	}
@@ -141,6 +361,192 @@ func (cfg *Apiconfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusForbidden, "that chirp doesn't belong to you")
 		return
 	}
-	cfg.DbQueries.DeleteChirp(r.Context(), chirp.ID)
+	if err := cfg.deleteOrTombstoneChirp(r.Context(), chirp); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// deleteOrTombstoneChirp removes a chirp for good unless someone replied to
+// or quoted it. In that case the body and history are wiped but the row
+// stays, so the replies keep their place in the thread.
+func (cfg *Apiconfig) deleteOrTombstoneChirp(ctx context.Context, chirp database.Chirp) error {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	replies, err := qtx.CountChirpReplies(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	quotes, err := qtx.CountChirpQuotes(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	// Plain rechirps have nothing of their own to keep around.
+	if err := qtx.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true}); err != nil {
+		return err
+	}
+	if err := enqueueWebhookEvent(ctx, qtx, chirp.UserID, eventChirpDeleted, webhookDeletedChirp{ID: chirp.ID, UserID: chirp.UserID}); err != nil {
+		return err
+	}
+	if replies == 0 && quotes == 0 {
+		if err := qtx.DeleteChirp(ctx, chirp.ID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	if err := qtx.DeleteChirpRevisions(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if err := clearChirpEntities(ctx, qtx, chirp.ID); err != nil {
+		return err
+	}
+	if err := qtx.TombstoneChirp(ctx, chirp.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (cfg *Apiconfig) UpdateChirp(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeChirpsWrite)
+	if !ok {
+		return
+	}
+	parsedId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid ID")
+		return
+	}
+	var chirpReq chirpRequest
+	if err := json.NewDecoder(r.Body).Decode(&chirpReq); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	limits, err := cfg.limitsFor(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	cleanMessage, err := validateChirpBody(chirpReq.Body, limits)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpForUpdate(r.Context(), parsedId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.DeletedAt.Valid) {
+		utils.RespondWithError(w, http.StatusNotFound, "chirp doesn't exist")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if chirp.UserID != userId {
+		utils.RespondWithError(w, http.StatusForbidden, "that chirp doesn't belong to you")
+		return
+	}
+	if chirp.RepostOfID.Valid && (isPlainRechirp(chirp) || cleanMessage == "") {
+		utils.RespondWithError(w, http.StatusBadRequest, "rechirps can't be edited")
+		return
+	}
+	if window := time.Duration(limits.EditWindow); window > 0 && time.Since(chirp.CreatedAt) > window {
+		utils.RespondWithError(w, http.StatusForbidden, "the edit window for that chirp has closed")
+		return
+	}
+	// The current version becomes a revision, dated when it was written.
+	if _, err := qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.UpdatedAt,
+	}); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	updated, err := qtx.UpdateChirp(r.Context(), database.UpdateChirpParams{Body: cleanMessage, ID: chirp.ID})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// Users the old version already mentioned have heard about the chirp.
+	previous, err := qtx.GetChirpMentions(r.Context(), []uuid.UUID{chirp.ID})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := unlinkStaleEntities(r.Context(), qtx, chirp.ID, updated.Body); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	mentioned, err := indexChirpEntities(r.Context(), qtx, updated.ID, updated.Body)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	mentioned = slices.DeleteFunc(mentioned, func(userId uuid.UUID) bool {
+		return slices.ContainsFunc(previous, func(m database.GetChirpMentionsRow) bool { return m.UserID == userId })
+	})
+	if err := announceMentions(r.Context(), qtx, updated, mentioned); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	chirpRes := []chirpResponse{newChirpResponse(updated)}
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, chirpRes); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, chirpRes[0])
+}
+
+func (cfg *Apiconfig) GetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	parsedId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid ID")
+		return
+	}
+	if chirp, err := cfg.DbQueries.GetChirp(r.Context(), parsedId); err != nil || chirp.DeletedAt.Valid {
+		utils.RespondWithError(w, http.StatusNotFound, "chirp doesn't exist")
+		return
+	}
+	revisions, err := cfg.DbQueries.GetChirpRevisions(r.Context(), parsedId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	revisionsRes := make([]chirpRevisionResponse, 0, len(revisions))
+	for _, rev := range revisions {
+		revisionsRes = append(revisionsRes, chirpRevisionResponse(rev))
+	}
+	utils.RespondWithJson(w, http.StatusOK, revisionsRes)
+}
@@ -1,16 +1,39 @@
 package api
 
 import (
+	"database/sql"
 	"sync/atomic"
 
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/entitlements"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/mailer"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/storage"
 )
 
 type Apiconfig struct {
 	FileserverHits atomic.Int32
+	DB             *sql.DB
 	DbQueries      *database.Queries
 	Platform       string
-	Secret         string
-	Expiration     int
-	PolkaKey       string
+	// Secret signs the tokens we email out and encrypts the JWT signing keys.
+	Secret     string
+	Keys       *auth.KeySet
+	AdminKey   string
+	Expiration int
+	// PolkaSecrets verify the signatures on Polka webhooks. A second secret
+	// is accepted while Polka rolls over to a new one.
+	PolkaSecrets []string
+	Storage      storage.Storage
+	Mailer       mailer.Mailer
+	// BaseURL is where this server is reached; links in emails point at the
+	// pages it serves under /app/, like verify.html and reset-password.html.
+	BaseURL string
+	// RequireVerifiedEmail keeps unverified accounts from logging in or
+	// posting chirps.
+	RequireVerifiedEmail bool
+	// Plans holds the limits of every plan, see limitsFor.
+	Plans entitlements.Plans
+
+	requests rateLimiter
 }
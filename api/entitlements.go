@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/entitlements"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+// limitsFor returns what the plan userId is on allows. A member on a plan
+// the config doesn't know yet still gets Chirpy Red, not the free limits.
+func (cfg *Apiconfig) limitsFor(ctx context.Context, userId uuid.UUID) (entitlements.Limits, error) {
+	plan, err := cfg.DbQueries.GetUserPlan(ctx, userId)
+	if err != nil {
+		return entitlements.Limits{}, err
+	}
+	if _, ok := cfg.Plans[plan]; !ok && plan != entitlements.FreePlan {
+		plan = defaultPlan
+	}
+	return cfg.Plans.For(plan), nil
+}
+
+// enforceRateLimit counts a request by userId against the rate limit of
+// their plan. It answers 429 once they're over it, or 401 when the account
+// is gone, and returns false in both cases.
+func (cfg *Apiconfig) enforceRateLimit(w http.ResponseWriter, r *http.Request, userId uuid.UUID) bool {
+	limits, err := cfg.limitsFor(r.Context(), userId)
+	// A JWT outlives the account it was issued for.
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusUnauthorized, "your token is invalid, get a new one")
+		return false
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return false
+	}
+	if limits.RequestsPerMinute > 0 {
+		if ok, wait := cfg.requests.allow(userId, limits.RequestsPerMinute); !ok {
+			respondRateLimited(w, wait)
+			return false
+		}
+	}
+	return true
+}
+
+// rateLimiter counts requests per user in fixed one minute windows. It only
+// lives in memory, so each instance of the server counts on its own: behind
+// a load balancer spreading requests over n instances, a user gets up to n
+// times their plan's limit.
+type rateLimiter struct {
+	mu     sync.Mutex
+	window time.Time
+	counts map[uuid.UUID]int
+}
+
+// allow records a request by userId and reports whether it's within limit,
+// along with how long until the window resets.
+func (l *rateLimiter) allow(userId uuid.UUID, limit int) (bool, time.Duration) {
+	now := time.Now()
+	window := now.Truncate(time.Minute)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !window.Equal(l.window) || l.counts == nil {
+		l.window = window
+		l.counts = make(map[uuid.UUID]int)
+	}
+	l.counts[userId]++
+	return l.counts[userId] <= limit, window.Add(time.Minute).Sub(now)
+}
+
+func respondRateLimited(w http.ResponseWriter, wait time.Duration) {
+	setRetryAfter(w, wait)
+	utils.RespondWithError(w, http.StatusTooManyRequests, "rate limit exceeded, upgrade to Chirpy Red for more")
+}
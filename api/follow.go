@@ -0,0 +1,162 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+type (
+	followResponse struct {
+		ID          uuid.UUID `json:"id"`
+		CreatedAt   time.Time `json:"created_at"`
+		IsChirpyRed bool      `json:"is_chirpy_red"`
+		FollowedAt  time.Time `json:"followed_at"`
+	}
+	followsPage struct {
+		Users      []followResponse `json:"users"`
+		Limit      int32            `json:"limit"`
+		NextCursor *string          `json:"next_cursor"`
+	}
+)
+
+func newFollowsPage(users []followResponse, limit int32) followsPage {
+	page := followsPage{Users: users, Limit: limit}
+	if len(users) > int(limit) {
+		page.Users = users[:limit]
+		last := page.Users[len(page.Users)-1]
+		cursor := encodeCursor(last.FollowedAt, last.ID)
+		page.NextCursor = &cursor
+	}
+	return page
+}
+
+func (cfg *Apiconfig) FollowUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeFollowsWrite)
+	if !ok {
+		return
+	}
+	followeeId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if followeeId == userId {
+		utils.RespondWithError(w, http.StatusBadRequest, "you can't follow yourself")
+		return
+	}
+	if _, err := cfg.DbQueries.GetUserById(r.Context(), followeeId); err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "user doesn't exist")
+		return
+	}
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	followed, err := qtx.FollowUser(r.Context(), database.FollowUserParams{FollowerID: userId, FolloweeID: followeeId})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// Following someone again is a no-op and isn't announced twice.
+	if followed > 0 {
+		err := enqueueWebhookEvent(r.Context(), qtx, followeeId, eventFollowCreated, webhookFollow{FollowerID: userId, FolloweeID: followeeId})
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *Apiconfig) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeFollowsWrite)
+	if !ok {
+		return
+	}
+	followeeId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if err := cfg.DbQueries.UnfollowUser(r.Context(), database.UnfollowUserParams{FollowerID: userId, FolloweeID: followeeId}); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *Apiconfig) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	followers, err := cfg.DbQueries.ListFollowers(r.Context(), database.ListFollowersParams{
+		FolloweeID:      userId,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	users := make([]followResponse, 0, len(followers))
+	for _, f := range followers {
+		users = append(users, followResponse(f))
+	}
+	utils.RespondWithJson(w, http.StatusOK, newFollowsPage(users, page.Limit))
+}
+
+func (cfg *Apiconfig) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	following, err := cfg.DbQueries.ListFollowing(r.Context(), database.ListFollowingParams{
+		FollowerID:      userId,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	users := make([]followResponse, 0, len(following))
+	for _, f := range following {
+		users = append(users, followResponse(f))
+	}
+	utils.RespondWithJson(w, http.StatusOK, newFollowsPage(users, page.Limit))
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+const maxTrends = 10
+
+var trendWindows = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+type trendResponse struct {
+	Tag           string  `json:"tag"`
+	Count         int64   `json:"count"`
+	PreviousCount int64   `json:"previous_count"`
+	Score         float64 `json:"score"`
+}
+
+// linkHashtags points a chirp at the hashtags in its body, creating the ones
+// we haven't seen before. Callers pass in a transaction scoped Queries.
+func linkHashtags(ctx context.Context, q *database.Queries, chirpId uuid.UUID, body string) error {
+	for _, tag := range utils.ExtractHashtags(body) {
+		hashtagId, err := q.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		if err := q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{ChirpID: chirpId, HashtagID: hashtagId}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *Apiconfig) GetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := utils.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid hashtag")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	chirps, err := cfg.DbQueries.ListHashtagChirps(r.Context(), database.ListHashtagChirpsParams{
+		Tag:             tag,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	chirpsRes := newChirpsPage(chirps, page.Limit)
+	if err := cfg.decorateChirps(r.Context(), cfg.viewerId(r), chirpsRes.Chirps); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, chirpsRes)
+}
+
+// GetTrends ranks hashtags by how fast they are being used right now,
+// comparing the requested window with the one before it.
+func (cfg *Apiconfig) GetTrends(w http.ResponseWriter, r *http.Request) {
+	windowParam := r.URL.Query().Get("window")
+	if windowParam == "" {
+		windowParam = "hour"
+	}
+	window, ok := trendWindows[windowParam]
+	if !ok {
+		utils.RespondWithError(w, http.StatusBadRequest, "window must be hour, day or week")
+		return
+	}
+	trends, err := cfg.DbQueries.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		WindowSeconds: int32(window.Seconds()),
+		Limit:         maxTrends,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	trendsRes := make([]trendResponse, 0, len(trends))
+	for _, t := range trends {
+		trendsRes = append(trendsRes, trendResponse{Tag: t.Tag, Count: t.CurrentCount, PreviousCount: t.PreviousCount, Score: t.Score})
+	}
+	utils.RespondWithJson(w, http.StatusOK, trendsRes)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+)
+
+// signingKeyRetention is how long a retired key keeps verifying tokens. Way
+// past the lifetime of any token, so services caching our JWKS have time to
+// catch up.
+const signingKeyRetention = 24 * time.Hour
+
+type rotateKeyResponse struct {
+	ActiveKeyID  string `json:"active_kid"`
+	RetiredKeyID string `json:"retired_kid"`
+}
+
+// LoadKeySet reads the signing keys from the database, creating the first
+// one when there is none yet.
+func LoadKeySet(ctx context.Context, q *database.Queries, secret string) (*auth.KeySet, error) {
+	keys, err := readSigningKeys(ctx, q, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(keys, func(key auth.SigningKey) bool { return !key.Retired() }) {
+		key, err := auth.GenerateSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := storeSigningKey(ctx, q, key, secret); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return auth.NewKeySet(keys, signingKeyRetention)
+}
+
+// ReloadKeys re-reads the signing keys every interval until ctx is done, so
+// a rotation made through another instance reaches this one too.
+func (cfg *Apiconfig) ReloadKeys(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		keys, err := readSigningKeys(ctx, cfg.DbQueries, cfg.Secret)
+		if err != nil {
+			log.Printf("Error has occurred loading signing keys. ERR: %v", err)
+			continue
+		}
+		if err := cfg.Keys.Replace(keys); err != nil {
+			log.Printf("Error has occurred loading signing keys. ERR: %v", err)
+		}
+	}
+}
+
+// readSigningKeys returns the stored keys that still verify tokens.
+func readSigningKeys(ctx context.Context, q *database.Queries, secret string) ([]auth.SigningKey, error) {
+	rows, err := q.ListSigningKeys(ctx, sql.NullTime{Time: time.Now().Add(-signingKeyRetention), Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]auth.SigningKey, 0, len(rows))
+	for _, row := range rows {
+		seed, err := auth.OpenKeySeed(row.PrivateKey, secret)
+		if err != nil {
+			return nil, err
+		}
+		key, err := auth.NewSigningKey(seed, row.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		key.RetiredAt = row.RetiredAt.Time
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func storeSigningKey(ctx context.Context, q *database.Queries, key auth.SigningKey, secret string) error {
+	sealed, err := auth.SealKeySeed(key.PrivateKey.Seed(), secret)
+	if err != nil {
+		return err
+	}
+	return q.CreateSigningKey(ctx, database.CreateSigningKeyParams{ID: key.ID, PrivateKey: sealed, CreatedAt: key.CreatedAt})
+}
+
+// GetJWKS publishes the public keys so other services can verify our tokens.
+func (cfg *Apiconfig) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.RespondWithJson(w, http.StatusOK, cfg.Keys.JWKS())
+}
+
+// RotateSigningKey switches to a new signing key. The old one keeps
+// verifying tokens for signingKeyRetention.
+func (cfg *Apiconfig) RotateSigningKey(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+	key, err := auth.GenerateSigningKey()
+	if err != nil {
+		log.Printf("Error has occurred generating a signing key. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	current := cfg.Keys.Active()
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if err := storeSigningKey(r.Context(), qtx, key, cfg.Secret); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	err = qtx.RetireSigningKey(r.Context(), database.RetireSigningKeyParams{
+		ID:        current.ID,
+		RetiredAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	retired := cfg.Keys.Rotate(key)
+	log.Printf("signing key rotated. active=%s retired=%s", key.ID, retired.ID)
+	utils.RespondWithJson(w, http.StatusOK, rotateKeyResponse{ActiveKeyID: key.ID, RetiredKeyID: retired.ID})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+// viewerId returns the caller of a public endpoint when they sent a valid
+// access token. Anonymous callers, or ones with a bad token, are not an error
+// here, they just don't get the personalised fields.
+func (cfg *Apiconfig) viewerId(r *http.Request) uuid.NullUUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	userId, err := cfg.validateToken(r.Context(), token, scopeChirpsRead)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userId, Valid: true}
+}
+
+// attachLikeStats fills in like_count and liked_by_me for a batch of chirps
+// with a single query.
+func (cfg *Apiconfig) attachLikeStats(ctx context.Context, viewer uuid.NullUUID, chirps []chirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		ids = append(ids, c.ID)
+	}
+	stats, err := cfg.DbQueries.GetChirpLikeStats(ctx, database.GetChirpLikeStatsParams{ViewerID: viewer, ChirpIds: ids})
+	if err != nil {
+		return err
+	}
+	byChirp := make(map[uuid.UUID]database.GetChirpLikeStatsRow, len(stats))
+	for _, s := range stats {
+		byChirp[s.ChirpID] = s
+	}
+	for i := range chirps {
+		s := byChirp[chirps[i].ID]
+		chirps[i].LikeCount = s.LikeCount
+		chirps[i].LikedByMe = s.LikedByMe
+	}
+	return nil
+}
+
+func (cfg *Apiconfig) LikeChirp(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeChirpsWrite)
+	if !ok {
+		return
+	}
+	chirpId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid ID")
+		return
+	}
+	chirp, err := cfg.DbQueries.GetChirp(r.Context(), chirpId)
+	if err != nil || chirp.DeletedAt.Valid {
+		utils.RespondWithError(w, http.StatusNotFound, "chirp doesn't exist")
+		return
+	}
+	if err := cfg.DbQueries.LikeChirp(r.Context(), database.LikeChirpParams{UserID: userId, ChirpID: chirp.ID}); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *Apiconfig) UnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeChirpsWrite)
+	if !ok {
+		return
+	}
+	chirpId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid ID")
+		return
+	}
+	if err := cfg.DbQueries.UnlikeChirp(r.Context(), database.UnlikeChirpParams{UserID: userId, ChirpID: chirpId}); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *Apiconfig) GetUserLikes(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	likes, err := cfg.DbQueries.ListUserLikes(r.Context(), database.ListUserLikesParams{
+		UserID:          userId,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	likesRes := chirpsPage{Chirps: make([]chirpResponse, 0, len(likes)), Limit: page.Limit}
+	if len(likes) > int(page.Limit) {
+		likes = likes[:page.Limit]
+		last := likes[len(likes)-1]
+		cursor := encodeCursor(last.LikedAt, last.Chirp.ID)
+		likesRes.NextCursor = &cursor
+	}
+	for _, l := range likes {
+		likesRes.Chirps = append(likesRes.Chirps, newChirpResponse(l.Chirp))
+	}
+	if err := cfg.decorateChirps(r.Context(), cfg.viewerId(r), likesRes.Chirps); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, likesRes)
+}
@@ -1,16 +1,26 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
 	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
 )
 
+const refreshTokenExpiry = 60 * 24 * time.Hour
+
 type (
 	loginRequest struct {
 		Email    string `json:"email"`
@@ -29,43 +39,167 @@ func (cfg *Apiconfig) Login(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
+	accountKey := strings.ToLower(strings.TrimSpace(loginReq.Email))
+	throttles := []throttleKey{{accountThrottle, accountKey}, {ipThrottle, clientIP(r)}}
+	for i, t := range throttles {
+		wait, err := cfg.reserveAttempt(r.Context(), t.policy, t.key)
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if wait > 0 {
+			// The attempt never happened, so it doesn't count against the
+			// throttles already reserved either.
+			for _, reserved := range throttles[:i] {
+				if err := cfg.releaseAttempt(r.Context(), reserved.policy, reserved.key); err != nil {
+					log.Printf("DB error has occurred: %v", err)
+				}
+			}
+			respondTooManyAttempts(w, wait)
+			return
+		}
+	}
+
+	// Unknown emails go through the same hash check against a dummy hash, so
+	// neither the answer nor the timing tells whether an account exists.
 	user, err := cfg.DbQueries.GetUserByEmail(r.Context(), loginReq.Email)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Email")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
-	isMatch, err := auth.CheckPasswordHash(loginReq.Password, user.Password)
+	hash := user.Password
+	if err != nil {
+		hash = dummyPasswordHash()
+	}
+	isMatch, err := auth.CheckPasswordHash(loginReq.Password, hash)
 	if err != nil {
 		log.Printf("Error has occurred comparing hashed password. ERR: %v", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
-	if !isMatch {
+	// A failure was already counted when the attempt was reserved.
+	if !isMatch || user.ID == uuid.Nil {
 		utils.RespondWithError(w, http.StatusUnauthorized, "email or password incorrect.")
 		return
 	}
-
-	jwt, err := auth.MakeJWT(user.ID, cfg.Secret, time.Minute*time.Duration(cfg.Expiration))
+	if err := cfg.clearFailures(r.Context(), accountThrottle, accountKey); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+	}
+	if err := cfg.releaseAttempt(r.Context(), ipThrottle, clientIP(r)); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+	}
+	if !cfg.requireVerified(w, user) {
+		return
+	}
+	_, mfaEnabled, err := cfg.confirmedTOTP(r.Context(), user.ID)
 	if err != nil {
-		log.Printf("Error has occurred creating the jwt. ERR: %v", err)
+		log.Printf("DB error has occurred: %v", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
-	refToken, err := auth.MakeRefreshToken()
+	if mfaEnabled {
+		challenge, err := auth.MakeMFAChallenge(user.ID, cfg.Keys, mfaChallengeExpiry)
+		if err != nil {
+			log.Printf("Error has occurred creating the mfa challenge. ERR: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		utils.RespondWithJson(w, http.StatusOK, mfaChallengeResponse{MFARequired: true, MFAChallenge: challenge})
+		return
+	}
+	cfg.respondWithTokens(w, r, user.ID)
+}
+
+// respondWithTokens hands out a fresh access/refresh token pair, the last
+// step of every way to log in.
+func (cfg *Apiconfig) respondWithTokens(w http.ResponseWriter, r *http.Request, userId uuid.UUID) {
+	sessionId := uuid.New()
+	jwt, err := auth.MakeSessionJWT(userId, sessionId, cfg.Keys, time.Minute*time.Duration(cfg.Expiration))
 	if err != nil {
-		log.Printf("Error has occurred creating the refersh token. ERR: %v", err)
+		log.Printf("Error has occurred creating the jwt. ERR: %v", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
-	rToken, err := cfg.DbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refToken,
-		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(60 * 24 * time.Hour)})
+	rToken, err := cfg.issueRefreshToken(r, cfg.DbQueries, userId, sessionId)
 	if err != nil {
-		log.Printf("DB error has occurred: %v", err)
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid token")
+		log.Printf("Error has occurred creating the refresh token. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 	loginRes := LoginResponse{AccessToken: jwt, RefreshToken: rToken}
 	utils.RespondWithJson(w, http.StatusOK, loginRes)
 }
+
+// issueRefreshToken stores a new refresh token in the given family along
+// with the device it was handed to. A login starts a new family, refreshing
+// continues the one of the presented token.
+func (cfg *Apiconfig) issueRefreshToken(r *http.Request, q *database.Queries, userId, familyId uuid.UUID) (string, error) {
+	refToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	lookupId, hash, err := auth.SplitRefreshToken(refToken)
+	if err != nil {
+		return "", err
+	}
+	err = q.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		ID:        lookupId,
+		TokenHash: hash,
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(refreshTokenExpiry),
+		FamilyID:  familyId,
+		UserAgent: r.UserAgent(),
+		Ip:        clientIP(r),
+	})
+	if err != nil {
+		return "", err
+	}
+	return refToken, nil
+}
+
+// lookupRefreshToken finds the row of a raw refresh token. The digest is
+// compared in constant time, the lookup id alone proves nothing.
+func (cfg *Apiconfig) lookupRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	lookupId, hash, err := auth.SplitRefreshToken(token)
+	if err != nil {
+		return database.RefreshToken{}, err
+	}
+	dbToken, err := cfg.DbQueries.GetRefreshToken(ctx, lookupId)
+	if err != nil {
+		return database.RefreshToken{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(dbToken.TokenHash)) != 1 {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return dbToken, nil
+}
+
+// clientIP is the address the request came from. X-Forwarded-For is
+// ignored, a client could put anything in it.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash is what passwords for unknown emails get checked
+// against. Hashed once, with the same parameters as real passwords.
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, err := auth.HashPassword("not the password of anybody")
+		if err != nil {
+			log.Printf("Error has occurred hashing password. ERR: %v\n", err)
+		}
+		dummyHash = hash
+	})
+	return dummyHash
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/media"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	maxUploadSize = 10 << 20
+	// Uploads have this long to be attached to a chirp before PruneMedia
+	// deletes them.
+	orphanedMediaAge   = 24 * time.Hour
+	orphanedMediaBatch = 100
+)
+
+var (
+	errBadUpload    = errors.New("bad upload")
+	errInvalidMedia = errors.New("invalid media_ids")
+)
+
+type (
+	mediaResponse struct {
+		ID          uuid.UUID         `json:"id"`
+		ContentType string            `json:"content_type"`
+		Width       int32             `json:"width"`
+		Height      int32             `json:"height"`
+		URL         string            `json:"url"`
+		Thumbnails  map[string]string `json:"thumbnails"`
+	}
+	avatarResponse struct {
+		URL        string            `json:"url"`
+		Thumbnails map[string]string `json:"thumbnails"`
+	}
+)
+
+func mediaKey(id uuid.UUID, variant string) string {
+	return "media/" + id.String() + "/" + variant
+}
+
+func (cfg *Apiconfig) mediaURLs(id uuid.UUID) (string, map[string]string) {
+	thumbs := make(map[string]string, len(media.ThumbnailSizes))
+	for _, size := range media.ThumbnailSizes {
+		thumbs[size.Name] = cfg.Storage.URL(mediaKey(id, size.Name))
+	}
+	return cfg.Storage.URL(mediaKey(id, "original")), thumbs
+}
+
+func (cfg *Apiconfig) newMediaResponse(m database.Medium) mediaResponse {
+	url, thumbs := cfg.mediaURLs(m.ID)
+	return mediaResponse{ID: m.ID, ContentType: m.ContentType, Width: m.Width, Height: m.Height, URL: url, Thumbnails: thumbs}
+}
+
+func (cfg *Apiconfig) newAvatarResponse(avatarId uuid.NullUUID) *avatarResponse {
+	if !avatarId.Valid {
+		return nil
+	}
+	url, thumbs := cfg.mediaURLs(avatarId.UUID)
+	return &avatarResponse{URL: url, Thumbnails: thumbs}
+}
+
+// storeUpload reads the "file" part of a multipart upload, cleans it up with
+// media.Process and stores the result plus its thumbnails.
+func (cfg *Apiconfig) storeUpload(w http.ResponseWriter, r *http.Request, userId uuid.UUID) (database.Medium, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return database.Medium{}, errBadUpload
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return database.Medium{}, errBadUpload
+	}
+	processed, err := media.Process(data)
+	if err != nil {
+		return database.Medium{}, err
+	}
+
+	m, err := cfg.DbQueries.CreateMedia(r.Context(), database.CreateMediaParams{
+		UserID:      userId,
+		ContentType: processed.ContentType,
+		Width:       int32(processed.Width),
+		Height:      int32(processed.Height),
+	})
+	if err != nil {
+		return database.Medium{}, err
+	}
+	blobs := map[string][]byte{"original": processed.Original}
+	for _, thumb := range processed.Thumbnails {
+		blobs[thumb.Name] = thumb.Data
+	}
+	for variant, data := range blobs {
+		if err := cfg.Storage.Put(r.Context(), mediaKey(m.ID, variant), bytes.NewReader(data)); err != nil {
+			cfg.discardMedia(r.Context(), m.ID)
+			return database.Medium{}, err
+		}
+	}
+	return m, nil
+}
+
+// discardMedia cleans up after an upload that failed half way.
+func (cfg *Apiconfig) discardMedia(ctx context.Context, id uuid.UUID) {
+	cfg.deleteMediaBlobs(ctx, id)
+	if err := cfg.DbQueries.DeleteMedia(ctx, id); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+	}
+}
+
+func (cfg *Apiconfig) deleteMediaBlobs(ctx context.Context, id uuid.UUID) {
+	for _, variant := range append([]string{"original"}, thumbnailNames()...) {
+		if err := cfg.Storage.Delete(ctx, mediaKey(id, variant)); err != nil {
+			log.Printf("Error has occurred deleting media blob. ERR: %v", err)
+		}
+	}
+}
+
+// PruneMedia deletes media nobody uses anymore, uploads never attached to a
+// chirp and replaced avatars, once they're orphanedMediaAge old. It checks
+// every interval until ctx is done.
+func (cfg *Apiconfig) PruneMedia(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pruned, err := cfg.pruneOrphanedMedia(ctx)
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+		}
+		if pruned > 0 {
+			log.Printf("Pruned %d orphaned media", pruned)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneOrphanedMedia deletes orphaned media a batch at a time until there
+// is none left, and returns how many it deleted.
+func (cfg *Apiconfig) pruneOrphanedMedia(ctx context.Context) (int, error) {
+	pruned := 0
+	for {
+		ids, err := cfg.DbQueries.DeleteOrphanedMedia(ctx, database.DeleteOrphanedMediaParams{
+			MinAgeMs: orphanedMediaAge.Milliseconds(),
+			Limit:    orphanedMediaBatch,
+		})
+		if err != nil {
+			return pruned, err
+		}
+		for _, id := range ids {
+			cfg.deleteMediaBlobs(ctx, id)
+		}
+		pruned += len(ids)
+		if len(ids) < orphanedMediaBatch {
+			return pruned, nil
+		}
+	}
+}
+
+func thumbnailNames() []string {
+	names := make([]string, 0, len(media.ThumbnailSizes))
+	for _, size := range media.ThumbnailSizes {
+		names = append(names, size.Name)
+	}
+	return names
+}
+
+// respondUploadError maps what storeUpload returned to a response.
+func respondUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "file is too large")
+	case errors.Is(err, errBadUpload):
+		utils.RespondWithError(w, http.StatusBadRequest, "expected a multipart upload with a file field")
+	case errors.Is(err, media.ErrUnsupportedType):
+		utils.RespondWithError(w, http.StatusUnsupportedMediaType, "only jpeg, png and gif images are supported")
+	case errors.Is(err, media.ErrTooLarge):
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		log.Printf("Error has occurred storing upload. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func (cfg *Apiconfig) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeChirpsWrite)
+	if !ok {
+		return
+	}
+	m, err := cfg.storeUpload(w, r, userId)
+	if err != nil {
+		respondUploadError(w, err)
+		return
+	}
+	utils.RespondWithJson(w, http.StatusCreated, cfg.newMediaResponse(m))
+}
+
+// UploadAvatar replaces the caller's avatar. The one it replaces is left
+// for PruneMedia, unless the caller attached it to a chirp as well.
+func (cfg *Apiconfig) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeProfileWrite)
+	if !ok {
+		return
+	}
+	m, err := cfg.storeUpload(w, r, userId)
+	if err != nil {
+		respondUploadError(w, err)
+		return
+	}
+	user, err := cfg.DbQueries.SetUserAvatar(r.Context(), database.SetUserAvatarParams{
+		AvatarID: uuid.NullUUID{UUID: m.ID, Valid: true},
+		ID:       userId,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		cfg.discardMedia(r.Context(), m.ID)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, cfg.newUserResponse(user))
+}
+
+// attachChirpMedia links already uploaded media to a new chirp, keeping the
+// order the client sent. Only media the author uploaded and hasn't used
+// elsewhere can be attached.
+func attachChirpMedia(ctx context.Context, q *database.Queries, userId, chirpId uuid.UUID, mediaIds []uuid.UUID) error {
+	if len(mediaIds) == 0 {
+		return nil
+	}
+	owned, err := q.GetUnattachedMedia(ctx, database.GetUnattachedMediaParams{UserID: userId, Ids: mediaIds})
+	if err != nil {
+		return err
+	}
+	if len(owned) != len(mediaIds) {
+		return errInvalidMedia
+	}
+	for i, id := range mediaIds {
+		if err := q.AttachChirpMedia(ctx, database.AttachChirpMediaParams{ChirpID: chirpId, MediaID: id, Position: int32(i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachMedia fills in the attached media for a batch of chirps.
+func (cfg *Apiconfig) attachMedia(ctx context.Context, chirps []chirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		ids = append(ids, c.ID)
+	}
+	rows, err := cfg.DbQueries.GetChirpMedia(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byChirp := make(map[uuid.UUID][]mediaResponse)
+	for _, m := range rows {
+		byChirp[m.ChirpID] = append(byChirp[m.ChirpID], cfg.newMediaResponse(database.Medium{
+			ID:          m.ID,
+			ContentType: m.ContentType,
+			Width:       m.Width,
+			Height:      m.Height,
+		}))
+	}
+	for i := range chirps {
+		chirps[i].Media = byChirp[chirps[i].ID]
+		if chirps[i].Media == nil {
+			chirps[i].Media = []mediaResponse{}
+		}
+	}
+	return nil
+}
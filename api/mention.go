@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+type mentionResponse struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// linkMentions resolves the @handles in a chirp body to user accounts and
+// stores where in the body each one sits. Handles that don't belong to
+// anybody are left as plain text. It returns who was mentioned.
+func linkMentions(ctx context.Context, q *database.Queries, chirpId uuid.UUID, body string) ([]uuid.UUID, error) {
+	mentions := utils.ExtractMentions(body)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	handles := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		handles = append(handles, m.Handle)
+	}
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+	byHandle := make(map[string]uuid.UUID, len(users))
+	for _, u := range users {
+		byHandle[u.Handle] = u.ID
+	}
+	var mentioned []uuid.UUID
+	for _, m := range mentions {
+		userId, ok := byHandle[m.Handle]
+		if !ok {
+			continue
+		}
+		if err := q.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID:     chirpId,
+			UserID:      userId,
+			StartOffset: int32(m.Start),
+			EndOffset:   int32(m.End),
+		}); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(mentioned, userId) {
+			mentioned = append(mentioned, userId)
+		}
+	}
+	return mentioned, nil
+}
+
+// announceMentions sends user.mentioned to everyone in mentioned who isn't
+// the chirp's author.
+func announceMentions(ctx context.Context, q *database.Queries, chirp database.Chirp, mentioned []uuid.UUID) error {
+	for _, userId := range mentioned {
+		if userId == chirp.UserID {
+			continue
+		}
+		if err := enqueueWebhookEvent(ctx, q, userId, eventUserMentioned, newWebhookChirp(chirp)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachMentions fills in the mention entities for a batch of chirps.
+func (cfg *Apiconfig) attachMentions(ctx context.Context, chirps []chirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		ids = append(ids, c.ID)
+	}
+	mentions, err := cfg.DbQueries.GetChirpMentions(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byChirp := make(map[uuid.UUID][]mentionResponse)
+	for _, m := range mentions {
+		byChirp[m.ChirpID] = append(byChirp[m.ChirpID], mentionResponse{UserID: m.UserID, Handle: m.Handle, Start: m.StartOffset, End: m.EndOffset})
+	}
+	for i := range chirps {
+		chirps[i].Mentions = byChirp[chirps[i].ID]
+		if chirps[i].Mentions == nil {
+			chirps[i].Mentions = []mentionResponse{}
+		}
+	}
+	return nil
+}
+
+func (cfg *Apiconfig) GetUserMentions(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	chirps, err := cfg.DbQueries.ListUserMentions(r.Context(), database.ListUserMentionsParams{
+		UserID:          userId,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	chirpsRes := newChirpsPage(chirps, page.Limit)
+	if err := cfg.decorateChirps(r.Context(), cfg.viewerId(r), chirpsRes.Chirps); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, chirpsRes)
+}
@@ -0,0 +1,446 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/webhook"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+// Events a webhook endpoint can subscribe to. Each goes to the endpoints of
+// the user it concerns: the author for chirps, the mentioned user for
+// mentions and the followed user for follows.
+const (
+	eventChirpCreated  = "chirp.created"
+	eventChirpDeleted  = "chirp.deleted"
+	eventUserMentioned = "user.mentioned"
+	eventFollowCreated = "follow.created"
+)
+
+var webhookEventTypes = []string{eventChirpCreated, eventChirpDeleted, eventUserMentioned, eventFollowCreated}
+
+const (
+	maxWebhookEndpoints  = 10
+	webhookDeliveryBatch = 20
+)
+
+var webhookClient = webhook.NewClient()
+
+type (
+	webhookEndpointRequest struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	webhookEndpointResponse struct {
+		ID                  uuid.UUID  `json:"id"`
+		URL                 string     `json:"url"`
+		Events              []string   `json:"events"`
+		CreatedAt           time.Time  `json:"created_at"`
+		ConsecutiveFailures int32      `json:"consecutive_failures"`
+		DisabledAt          *time.Time `json:"disabled_at"`
+		// Only set once, in the response to creating the endpoint.
+		Secret string `json:"secret,omitempty"`
+	}
+	webhookDeliveryResponse struct {
+		ID             uuid.UUID       `json:"id"`
+		EventType      string          `json:"event_type"`
+		Payload        json.RawMessage `json:"payload"`
+		Status         string          `json:"status"`
+		Attempts       int32           `json:"attempts"`
+		NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+		LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+		ResponseStatus *int32          `json:"response_status"`
+		Error          *string         `json:"error"`
+		CreatedAt      time.Time       `json:"created_at"`
+	}
+	webhookDeliveriesPage struct {
+		Deliveries []webhookDeliveryResponse `json:"deliveries"`
+		Limit      int32                     `json:"limit"`
+		NextCursor *string                   `json:"next_cursor"`
+	}
+	// outboundEvent is the body of every delivery.
+	outboundEvent struct {
+		ID        uuid.UUID `json:"id"`
+		Type      string    `json:"type"`
+		CreatedAt time.Time `json:"created_at"`
+		Data      any       `json:"data"`
+	}
+	webhookChirp struct {
+		ID         uuid.UUID     `json:"id"`
+		Body       string        `json:"body"`
+		UserID     uuid.UUID     `json:"user_id"`
+		CreatedAt  time.Time     `json:"created_at"`
+		ParentID   uuid.NullUUID `json:"parent_id"`
+		RepostOfID uuid.NullUUID `json:"repost_of_id"`
+	}
+	webhookDeletedChirp struct {
+		ID     uuid.UUID `json:"id"`
+		UserID uuid.UUID `json:"user_id"`
+	}
+	webhookFollow struct {
+		FollowerID uuid.UUID `json:"follower_id"`
+		FolloweeID uuid.UUID `json:"followee_id"`
+	}
+)
+
+func newWebhookEndpointResponse(endpoint database.WebhookEndpoint) webhookEndpointResponse {
+	return webhookEndpointResponse{
+		ID:                  endpoint.ID,
+		URL:                 endpoint.Url,
+		Events:              endpoint.Events,
+		CreatedAt:           endpoint.CreatedAt,
+		ConsecutiveFailures: endpoint.ConsecutiveFailures,
+		DisabledAt:          nullTimePtr(endpoint.DisabledAt),
+	}
+}
+
+func newWebhookDeliveryResponse(delivery database.WebhookDelivery) webhookDeliveryResponse {
+	res := webhookDeliveryResponse{
+		ID:            delivery.ID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		LastAttemptAt: nullTimePtr(delivery.LastAttemptAt),
+		CreatedAt:     delivery.CreatedAt,
+	}
+	if delivery.Status == "pending" {
+		res.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if delivery.ResponseStatus.Valid {
+		res.ResponseStatus = &delivery.ResponseStatus.Int32
+	}
+	if delivery.Error.Valid {
+		res.Error = &delivery.Error.String
+	}
+	return res
+}
+
+func newWebhookChirp(chirp database.Chirp) webhookChirp {
+	return webhookChirp{ID: chirp.ID, Body: chirp.Body, UserID: chirp.UserID, CreatedAt: chirp.CreatedAt, ParentID: chirp.ParentID, RepostOfID: chirp.RepostOfID}
+}
+
+// enqueueWebhookEvent queues an event for userId's endpoints. Pass it the
+// transaction of the change the event reports, so the event is sent exactly
+// when the change is committed.
+func enqueueWebhookEvent(ctx context.Context, q *database.Queries, userId uuid.UUID, eventType string, data any) error {
+	payload, err := json.Marshal(outboundEvent{ID: uuid.New(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+	return q.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+		EventType: eventType,
+		Payload:   payload,
+		UserID:    userId,
+	})
+}
+
+func (cfg *Apiconfig) CreateWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	var req webhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// Plain http is fine for trying webhooks out on the dev platform.
+	if err := webhook.ValidateURL(r.Context(), req.URL, cfg.Platform == "dev"); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Events) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "a webhook needs at least one event")
+		return
+	}
+	for _, event := range req.Events {
+		if !slices.Contains(webhookEventTypes, event) {
+			utils.RespondWithError(w, http.StatusBadRequest, "unknown event: "+event)
+			return
+		}
+	}
+	count, err := cfg.DbQueries.CountWebhookEndpoints(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if count >= maxWebhookEndpoints {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Sprintf("you can have up to %d webhooks", maxWebhookEndpoints))
+		return
+	}
+
+	secret, err := auth.MakeWebhookSecret()
+	if err != nil {
+		log.Printf("Error has occurred creating the webhook secret. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	slices.Sort(req.Events)
+	endpoint, err := cfg.DbQueries.CreateWebhookEndpoint(r.Context(), database.CreateWebhookEndpointParams{
+		UserID: userId,
+		Url:    req.URL,
+		Secret: secret,
+		Events: slices.Compact(req.Events),
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	res := newWebhookEndpointResponse(endpoint)
+	res.Secret = secret
+	utils.RespondWithJson(w, http.StatusCreated, res)
+}
+
+func (cfg *Apiconfig) GetWebhookEndpoints(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	endpoints, err := cfg.DbQueries.ListWebhookEndpoints(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	res := make([]webhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		res = append(res, newWebhookEndpointResponse(endpoint))
+	}
+	utils.RespondWithJson(w, http.StatusOK, res)
+}
+
+func (cfg *Apiconfig) DeleteWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	endpointId, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	deleted, err := cfg.DbQueries.DeleteWebhookEndpoint(r.Context(), database.DeleteWebhookEndpointParams{ID: endpointId, UserID: userId})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if deleted == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EnableWebhookEndpoint turns an endpoint that was disabled for failing
+// back on. Deliveries given up on in the meantime aren't sent again.
+func (cfg *Apiconfig) EnableWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	endpointId, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	enabled, err := cfg.DbQueries.EnableWebhookEndpoint(r.Context(), database.EnableWebhookEndpointParams{ID: endpointId, UserID: userId})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if enabled == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries is the delivery log of one endpoint, newest first.
+func (cfg *Apiconfig) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	endpointId, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	endpoint, err := cfg.DbQueries.GetWebhookEndpoint(r.Context(), endpointId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && endpoint.UserID != userId) {
+		utils.RespondWithError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	deliveries, err := cfg.DbQueries.ListWebhookDeliveries(r.Context(), database.ListWebhookDeliveriesParams{
+		EndpointID:      endpoint.ID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	res := webhookDeliveriesPage{Deliveries: make([]webhookDeliveryResponse, 0, len(deliveries)), Limit: page.Limit}
+	if len(deliveries) > int(page.Limit) {
+		deliveries = deliveries[:page.Limit]
+		last := deliveries[len(deliveries)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		res.NextCursor = &cursor
+	}
+	for _, delivery := range deliveries {
+		res.Deliveries = append(res.Deliveries, newWebhookDeliveryResponse(delivery))
+	}
+	utils.RespondWithJson(w, http.StatusOK, res)
+}
+
+// DeliverWebhooks sends queued webhook deliveries every interval until ctx
+// is done. Several servers can run it side by side, each delivery is leased
+// to one of them at a time.
+func (cfg *Apiconfig) DeliverWebhooks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		claimedAt := time.Now()
+		deliveries, err := cfg.DbQueries.ClaimWebhookDeliveries(ctx, database.ClaimWebhookDeliveriesParams{
+			LeaseMs: webhook.Lease(webhookDeliveryBatch).Milliseconds(),
+			Limit:   webhookDeliveryBatch,
+		})
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+		}
+		for _, delivery := range deliveries {
+			// What's left goes back to the queue once the lease runs out,
+			// rather than risk another worker sending it too.
+			if !webhook.LeaseHeld(webhookDeliveryBatch, claimedAt, time.Now()) {
+				break
+			}
+			if err := cfg.deliverWebhook(ctx, delivery); err != nil {
+				log.Printf("DB error has occurred: %v", err)
+			}
+		}
+		// A full batch means more are probably due, so go again right away.
+		if len(deliveries) == webhookDeliveryBatch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverWebhook makes one attempt at a delivery and records how it went.
+// Only errors from recording it are returned.
+func (cfg *Apiconfig) deliverWebhook(ctx context.Context, delivery database.WebhookDelivery) error {
+	endpoint, err := cfg.DbQueries.GetWebhookEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return err
+	}
+	if endpoint.DisabledAt.Valid {
+		return cfg.DbQueries.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+			ID:    delivery.ID,
+			Error: sql.NullString{String: "endpoint is disabled", Valid: true},
+		})
+	}
+
+	status, sendErr := sendWebhook(ctx, endpoint, delivery)
+	responseStatus := sql.NullInt32{Int32: int32(status), Valid: status != 0}
+	if sendErr == nil {
+		err := cfg.DbQueries.MarkWebhookDeliverySucceeded(ctx, database.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			ResponseStatus: responseStatus,
+		})
+		if err != nil {
+			return err
+		}
+		return cfg.DbQueries.RecordWebhookEndpointSuccess(ctx, endpoint.ID)
+	}
+
+	var retry sql.NullInt64
+	if delay, ok := webhook.NextRetry(delivery.Attempts + 1); ok {
+		retry = sql.NullInt64{Int64: delay.Milliseconds(), Valid: true}
+	}
+	err = cfg.DbQueries.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+		RetryMs:        retry,
+		ResponseStatus: responseStatus,
+		Error:          sql.NullString{String: sendErr.Error(), Valid: true},
+		ID:             delivery.ID,
+	})
+	if err != nil {
+		return err
+	}
+	failures, err := cfg.DbQueries.RecordWebhookEndpointFailure(ctx, endpoint.ID)
+	if err != nil {
+		return err
+	}
+	if !webhook.ShouldDisable(failures) {
+		return nil
+	}
+	disabled, err := cfg.DbQueries.DisableWebhookEndpoint(ctx, endpoint.ID)
+	if err != nil {
+		return err
+	}
+	if disabled > 0 {
+		log.Printf("Disabled webhook endpoint %s after %d failed deliveries", endpoint.ID, failures)
+	}
+	return nil
+}
+
+// sendWebhook posts a delivery, signed the way Polka signs its webhooks to
+// us, and returns the response status if there was a response.
+func sendWebhook(ctx context.Context, endpoint database.WebhookEndpoint, delivery database.WebhookDelivery) (int, error) {
+	now := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chirpy-Webhooks")
+	req.Header.Set("Chirpy-Event", delivery.EventType)
+	req.Header.Set("Chirpy-Delivery", delivery.ID.String())
+	req.Header.Set("Chirpy-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("Chirpy-Signature", auth.SignWebhook(endpoint.Secret, now, delivery.Payload))
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("endpoint answered %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/mailer"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+)
+
+const passwordResetTokenTTL = time.Hour
+
+type (
+	forgotPasswordRequest struct {
+		Email string `json:"email"`
+	}
+	resetPasswordRequest struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+)
+
+func (cfg *Apiconfig) sendPasswordResetEmail(ctx context.Context, user database.User) error {
+	token, hash, err := auth.MakeSignedToken(cfg.Secret)
+	if err != nil {
+		return err
+	}
+	err = cfg.DbQueries.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: hash,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/app/reset-password.html?token=%s", cfg.BaseURL, url.QueryEscape(token))
+	return cfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body:    fmt.Sprintf("Hi @%s,\n\nSomeone asked to reset the password of your Chirpy account. Pick a new one by following this link:\n\n%s\n\nThe link expires in 1 hour. If it wasn't you, you can ignore this email and your password stays the same.\n", user.Handle, link),
+	})
+}
+
+// ForgotPassword mails a reset link. Like ResendVerification it always
+// answers 202 so it doesn't tell who has an account.
+func (cfg *Apiconfig) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	user, err := cfg.DbQueries.GetUserByEmail(r.Context(), req.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("DB error has occurred: %v", err)
+	}
+	if err == nil {
+		// Like ResendVerification, don't let the mail server's latency give
+		// away that the address is registered.
+		go func() {
+			if err := cfg.sendPasswordResetEmail(context.Background(), user); err != nil {
+				log.Printf("Error has occurred sending password reset email. ERR: %v", err)
+			}
+		}()
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword sets a new password from an emailed token and logs the user
+// out everywhere, so whoever might have had the old password loses access.
+func (cfg *Apiconfig) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if req.Password == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "password can't be empty")
+		return
+	}
+	hash, err := auth.ValidateSignedToken(req.Token, cfg.Secret)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	reset, err := cfg.DbQueries.GetPasswordResetToken(r.Context(), hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	hashedPwd, err := auth.HashPassword(req.Password)
+	if err != nil {
+		log.Printf("Error has occurred hashing password. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	// Used and expired tokens are filtered out by the update itself, so a
+	// token can't be spent twice even by concurrent requests.
+	claimed, err := qtx.UsePasswordResetToken(r.Context(), hash)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if claimed == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	if err := qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{ID: reset.UserID, Password: hashedPwd}); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := qtx.InvalidatePasswordResetTokens(r.Context(), reset.UserID); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := qtx.RevokeUserTokens(r.Context(), reset.UserID); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// Proving access to the mailbox lifts a lockout from failed logins.
+	if user, err := cfg.DbQueries.GetUserById(r.Context(), reset.UserID); err == nil {
+		if err := cfg.clearFailures(r.Context(), accountThrottle, strings.ToLower(user.Email)); err != nil {
+			log.Printf("DB error has occurred: %v", err)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxWebsiteLength     = 100
+)
+
+type (
+	// Every field is optional, only the ones sent get updated.
+	profileRequest struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+	profileResponse struct {
+		ID             uuid.UUID       `json:"id"`
+		CreatedAt      time.Time       `json:"created_at"`
+		Handle         string          `json:"handle"`
+		DisplayName    string          `json:"display_name"`
+		Bio            string          `json:"bio"`
+		Location       string          `json:"location"`
+		Website        string          `json:"website"`
+		IsChirpyRed    bool            `json:"is_chirpy_red"`
+		Avatar         *avatarResponse `json:"avatar"`
+		ChirpCount     int64           `json:"chirp_count"`
+		FollowerCount  int64           `json:"follower_count"`
+		FollowingCount int64           `json:"following_count"`
+	}
+)
+
+func toNullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// validate normalizes the handle and checks the length limits of the free
+// text fields. An empty website clears it, anything else has to be a link.
+func (req *profileRequest) validate() error {
+	if req.Handle != nil {
+		handle := utils.NormalizeHandle(*req.Handle)
+		if handle == "" {
+			return errors.New("invalid handle")
+		}
+		req.Handle = &handle
+	}
+	if req.DisplayName != nil && utf8.RuneCountInString(*req.DisplayName) > maxDisplayNameLength {
+		return errors.New("display name is too long")
+	}
+	if req.Bio != nil && utf8.RuneCountInString(*req.Bio) > maxBioLength {
+		return errors.New("bio is too long")
+	}
+	if req.Location != nil && utf8.RuneCountInString(*req.Location) > maxLocationLength {
+		return errors.New("location is too long")
+	}
+	if req.Website != nil && *req.Website != "" {
+		if len(*req.Website) > maxWebsiteLength {
+			return errors.New("website is too long")
+		}
+		u, err := url.Parse(*req.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("invalid website")
+		}
+	}
+	return nil
+}
+
+func (cfg *Apiconfig) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeProfileWrite)
+	if !ok {
+		return
+	}
+	var req profileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := req.validate(); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := cfg.DbQueries.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		Handle:      toNullString(req.Handle),
+		DisplayName: toNullString(req.DisplayName),
+		Bio:         toNullString(req.Bio),
+		Location:    toNullString(req.Location),
+		Website:     toNullString(req.Website),
+		ID:          userId,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "users_handle_key" {
+		utils.RespondWithError(w, http.StatusConflict, "handle already taken")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, cfg.newUserResponse(user))
+}
+
+// GetProfile is the public view of a user, so it must never expose the email.
+func (cfg *Apiconfig) GetProfile(w http.ResponseWriter, r *http.Request) {
+	handle := utils.NormalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid handle")
+		return
+	}
+	profile, err := cfg.DbQueries.GetUserProfileByHandle(r.Context(), handle)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "user doesn't exist")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, profileResponse{
+		ID:             profile.ID,
+		CreatedAt:      profile.CreatedAt,
+		Handle:         profile.Handle,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		Location:       profile.Location,
+		Website:        profile.Website,
+		IsChirpyRed:    profile.IsChirpyRed,
+		Avatar:         cfg.newAvatarResponse(profile.AvatarID),
+		ChirpCount:     profile.ChirpCount,
+		FollowerCount:  profile.FollowerCount,
+		FollowingCount: profile.FollowingCount,
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/google/uuid"
+)
+
+type repostResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Deleted   bool      `json:"deleted"`
+}
+
+// isPlainRechirp tells a plain rechirp, which only points at the original,
+// apart from a quote-chirp that adds a body of its own. A deleted quote has
+// a blank body too but is neither.
+func isPlainRechirp(chirp database.Chirp) bool {
+	return chirp.RepostOfID.Valid && chirp.Body == "" && !chirp.DeletedAt.Valid
+}
+
+// attachReposts embeds the original chirp into rechirps and quote-chirps and
+// fills in the rechirp/quote counters of every chirp in the batch.
+func (cfg *Apiconfig) attachReposts(ctx context.Context, chirps []chirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(chirps))
+	var originalIds []uuid.UUID
+	for _, c := range chirps {
+		ids = append(ids, c.ID)
+		if c.RepostOfID.Valid {
+			originalIds = append(originalIds, c.RepostOfID.UUID)
+		}
+	}
+
+	stats, err := cfg.DbQueries.GetChirpRepostStats(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byChirp := make(map[uuid.UUID]database.GetChirpRepostStatsRow, len(stats))
+	for _, s := range stats {
+		byChirp[s.ChirpID] = s
+	}
+
+	originals := make(map[uuid.UUID]database.Chirp, len(originalIds))
+	if len(originalIds) > 0 {
+		rows, err := cfg.DbQueries.GetChirpsByIds(ctx, originalIds)
+		if err != nil {
+			return err
+		}
+		for _, o := range rows {
+			originals[o.ID] = o
+		}
+	}
+
+	for i := range chirps {
+		s := byChirp[chirps[i].ID]
+		chirps[i].RechirpCount = s.RechirpCount
+		chirps[i].QuoteCount = s.QuoteCount
+		if !chirps[i].RepostOfID.Valid {
+			continue
+		}
+		if o, ok := originals[chirps[i].RepostOfID.UUID]; ok {
+			chirps[i].RepostOf = &repostResponse{
+				ID:        o.ID,
+				Body:      o.Body,
+				CreatedAt: o.CreatedAt,
+				UserID:    o.UserID,
+				Deleted:   o.DeletedAt.Valid,
+			}
+		}
+	}
+	return nil
+}
@@ -1,46 +1,105 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
 	"github.com/Israel-Andrade-P/Chirpy.git/utils"
 )
 
 type RefreshResponse struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
+// Refresh trades a refresh token for a new access token and a new refresh
+// token of the same family, the old one stops working. Seeing a token that
+// was already traded in means one of the two parties using it stole it, so
+// the whole family gets revoked and both have to log in again. A token that
+// was logged out is just refused.
 func (cfg *Apiconfig) Refresh(w http.ResponseWriter, r *http.Request) {
 	rToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusUnauthorized, "please autenticate yourself")
 		return
 	}
-	dbToken, err := cfg.DbQueries.GetRefreshToken(r.Context(), rToken)
+	dbToken, err := cfg.lookupRefreshToken(r.Context(), rToken)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusUnauthorized, "no token, please log in")
 		return
 	}
+	if dbToken.RevokedAt.Valid {
+		log.Printf("token revoked at: %v", dbToken.RevokedAt.Time)
+		if dbToken.RotatedAt.Valid {
+			cfg.revokeReusedFamily(r.Context(), dbToken)
+		}
+		utils.RespondWithError(w, http.StatusUnauthorized, "token revoked")
+		return
+	}
 	if dbToken.ExpiresAt.Before(time.Now()) {
 		utils.RespondWithError(w, http.StatusUnauthorized, "expired token, please log in")
 		return
 	}
-	if dbToken.RevokedAt.Valid {
-		log.Printf("token revoked at: %v", dbToken.RevokedAt.Time)
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	claimed, err := qtx.ClaimRefreshToken(r.Context(), dbToken.ID)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if claimed == 0 {
+		// Another request traded the token in or logged it out between our
+		// read and now.
+		tx.Rollback()
+		if current, err := cfg.DbQueries.GetRefreshToken(r.Context(), dbToken.ID); err != nil {
+			log.Printf("DB error has occurred: %v", err)
+		} else if current.RotatedAt.Valid {
+			cfg.revokeReusedFamily(r.Context(), dbToken)
+		}
 		utils.RespondWithError(w, http.StatusUnauthorized, "token revoked")
 		return
 	}
+	newToken, err := cfg.issueRefreshToken(r, qtx, dbToken.UserID, dbToken.FamilyID)
+	if err != nil {
+		log.Printf("Error has occurred creating the refresh token. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
 
-	aToken, err := auth.MakeJWT(dbToken.UserID, cfg.Secret, time.Minute*time.Duration(cfg.Expiration))
+	aToken, err := auth.MakeSessionJWT(dbToken.UserID, dbToken.FamilyID, cfg.Keys, time.Minute*time.Duration(cfg.Expiration))
 	if err != nil {
 		log.Printf("Error has occurred creating the jwt. ERR: %v", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
-	utils.RespondWithJson(w, http.StatusOK, RefreshResponse{AccessToken: aToken})
+	utils.RespondWithJson(w, http.StatusOK, RefreshResponse{AccessToken: aToken, RefreshToken: newToken})
+}
+
+// revokeReusedFamily kills every token descending from the same login as a
+// refresh token that was presented again after being traded in.
+func (cfg *Apiconfig) revokeReusedFamily(ctx context.Context, dbToken database.RefreshToken) {
+	log.Printf("SECURITY: revoked refresh token reused. user=%v family=%v, revoking the family", dbToken.UserID, dbToken.FamilyID)
+	if err := cfg.DbQueries.RevokeTokenFamily(ctx, dbToken.FamilyID); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+	}
 }
 
 func (cfg *Apiconfig) RevokeToken(w http.ResponseWriter, r *http.Request) {
@@ -49,6 +108,8 @@ func (cfg *Apiconfig) RevokeToken(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusUnauthorized, "please autenticate yourself")
 		return
 	}
-	cfg.DbQueries.RevokeToken(r.Context(), rToken)
+	if dbToken, err := cfg.lookupRefreshToken(r.Context(), rToken); err == nil {
+		cfg.DbQueries.RevokeToken(r.Context(), dbToken.ID)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+type searchResultResponse struct {
+	chirpResponse
+	Rank float32 `json:"rank"`
+	// Snippet is HTML with the matches in <mark> tags, the rest escaped.
+	Snippet string `json:"snippet"`
+}
+
+func (cfg *Apiconfig) SearchChirps(w http.ResponseWriter, r *http.Request) {
+	query, err := utils.BuildTsQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	// Results are ordered by rank, which the (created_at, id) cursor of the
+	// other listings can't page through, so only the first page is served.
+	if r.URL.Query().Has("cursor") {
+		utils.RespondWithError(w, http.StatusBadRequest, "search results can't be paged with a cursor")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var author uuid.NullUUID
+	if authorId := r.URL.Query().Get("author_id"); authorId != "" {
+		userId, err := uuid.Parse(authorId)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		author = uuid.NullUUID{UUID: userId, Valid: true}
+	}
+
+	results, err := cfg.DbQueries.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:    query,
+		AuthorID: author,
+		Limit:    page.Limit,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	chirpsRes := make([]chirpResponse, 0, len(results))
+	for _, res := range results {
+		chirpsRes = append(chirpsRes, newChirpResponse(res.Chirp))
+	}
+	if err := cfg.decorateChirps(r.Context(), cfg.viewerId(r), chirpsRes); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	searchRes := make([]searchResultResponse, 0, len(results))
+	for i, res := range results {
+		searchRes = append(searchRes, searchResultResponse{
+			chirpResponse: chirpsRes[i],
+			Rank:          res.Rank,
+			Snippet:       res.Snippet,
+		})
+	}
+	utils.RespondWithJson(w, http.StatusOK, searchRes)
+}
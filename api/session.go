@@ -0,0 +1,92 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+type sessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+func (cfg *Apiconfig) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userId, sessionId, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	sessions, err := cfg.DbQueries.ListSessions(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	res := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, newSessionResponse(session, sessionId))
+	}
+	utils.RespondWithJson(w, http.StatusOK, res)
+}
+
+func newSessionResponse(session database.ListSessionsRow, currentId uuid.UUID) sessionResponse {
+	return sessionResponse{
+		ID:         session.FamilyID,
+		UserAgent:  session.UserAgent,
+		IP:         session.Ip,
+		SignedInAt: session.SignedInAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.FamilyID == currentId,
+	}
+}
+
+// RevokeSession logs one of the caller's devices out. Refreshing stops
+// working, and so do the access tokens already handed to it.
+func (cfg *Apiconfig) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	sessionId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	revoked, err := cfg.DbQueries.RevokeSession(r.Context(), database.RevokeSessionParams{UserID: userId, FamilyID: sessionId})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if revoked == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions logs the caller out everywhere except the session
+// their access token belongs to.
+func (cfg *Apiconfig) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userId, sessionId, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.DbQueries.RevokeOtherSessions(r.Context(), database.RevokeOtherSessionsParams{UserID: userId, FamilyID: sessionId})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPlan = "red"
+	// How long a member keeps Chirpy Red after a failed payment, for Polka
+	// to retry the charge.
+	subscriptionGracePeriod = 7 * 24 * time.Hour
+)
+
+var errUnknownWebhookUser = &webhookError{http.StatusBadRequest, "user doesn't exist"}
+
+// activateSubscription handles both user.upgraded and subscription.renewed:
+// either way the user is a member until the end of the new period.
+func activateSubscription(ctx context.Context, q *database.Queries, userId uuid.UUID, data userInfo, sentAt time.Time) error {
+	plan := data.Plan
+	if plan == "" {
+		plan = defaultPlan
+	}
+	periodEnd := time.Now().AddDate(0, 1, 0)
+	if data.CurrentPeriodEnd != nil {
+		periodEnd = *data.CurrentPeriodEnd
+	}
+	// The column has no time zone, it holds UTC like the others and is
+	// compared with NOW() AT TIME ZONE 'UTC'.
+	periodEnd = periodEnd.UTC()
+	upgraded, err := q.UpdateChirpyRed(ctx, database.UpdateChirpyRedParams{ID: userId, IsChirpyRed: true})
+	if err != nil {
+		return err
+	}
+	if upgraded == 0 {
+		return errUnknownWebhookUser
+	}
+	return q.ActivateSubscription(ctx, database.ActivateSubscriptionParams{
+		UserID:           userId,
+		Plan:             plan,
+		CurrentPeriodEnd: periodEnd,
+		LastEventAt:      sql.NullTime{Time: sentAt, Valid: true},
+	})
+}
+
+// failSubscriptionPayment starts the grace period. The member keeps Chirpy
+// Red until ExpireSubscriptions finds it over.
+func failSubscriptionPayment(ctx context.Context, q *database.Queries, userId uuid.UUID, data userInfo, sentAt time.Time) error {
+	updated, err := q.MarkSubscriptionPastDue(ctx, database.MarkSubscriptionPastDueParams{
+		GraceMs:     subscriptionGracePeriod.Milliseconds(),
+		LastEventAt: sql.NullTime{Time: sentAt, Valid: true},
+		UserID:      userId,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return &webhookError{http.StatusBadRequest, "user has no active subscription"}
+	}
+	return nil
+}
+
+func cancelSubscription(ctx context.Context, q *database.Queries, userId uuid.UUID, data userInfo, sentAt time.Time) error {
+	downgraded, err := q.UpdateChirpyRed(ctx, database.UpdateChirpyRedParams{ID: userId, IsChirpyRed: false})
+	if err != nil {
+		return err
+	}
+	if downgraded == 0 {
+		return errUnknownWebhookUser
+	}
+	return q.CancelSubscription(ctx, database.CancelSubscriptionParams{
+		UserID:      userId,
+		LastEventAt: sql.NullTime{Time: sentAt, Valid: true},
+	})
+}
+
+// ExpireSubscriptions takes Chirpy Red away from members whose period, or
+// grace period, ran out without a renewal. It checks every interval until
+// ctx is done.
+func (cfg *Apiconfig) ExpireSubscriptions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		expired, err := cfg.DbQueries.ExpireSubscriptions(ctx)
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+		} else if expired > 0 {
+			log.Printf("Expired %d Chirpy Red subscriptions", expired)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
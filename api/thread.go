@@ -0,0 +1,153 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// How many levels of replies a thread shows below the chirp.
+	maxThreadDepth = 4
+	// How many replies each nested reply shows. The chirp's own replies are
+	// paged with the usual limit and cursor instead.
+	maxNestedReplies = 10
+)
+
+type (
+	threadChirpResponse struct {
+		ID         uuid.UUID              `json:"id"`
+		Body       string                 `json:"body"`
+		CreatedAt  time.Time              `json:"created_at"`
+		UpdatedAt  time.Time              `json:"updated_at"`
+		UserID     uuid.UUID              `json:"user_id"`
+		Edited     bool                   `json:"edited"`
+		ParentID   uuid.NullUUID          `json:"parent_id"`
+		Deleted    bool                   `json:"deleted"`
+		ReplyCount int64                  `json:"reply_count"`
+		Replies    []*threadChirpResponse `json:"replies,omitempty"`
+	}
+	threadResponse struct {
+		Ancestors []*threadChirpResponse `json:"ancestors"`
+		Chirp     *threadChirpResponse   `json:"chirp"`
+		// NextCursor pages through the chirp's direct replies.
+		NextCursor *string `json:"next_cursor"`
+	}
+)
+
+// GetChirpThread shows a chirp with its ancestors and a page of its replies.
+// Nested replies are cut off at maxThreadDepth levels and maxNestedReplies
+// per chirp; their reply_count says when there is more to open.
+func (cfg *Apiconfig) GetChirpThread(w http.ResponseWriter, r *http.Request) {
+	parsedId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid ID")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	chirp, err := cfg.DbQueries.GetChirp(r.Context(), parsedId)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "chirp doesn't exist")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	ancestors, err := cfg.DbQueries.GetChirpAncestors(r.Context(), chirp.ID)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	descendants, err := cfg.DbQueries.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ParentID:        uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+		NestedLimit:     maxNestedReplies,
+		MaxDepth:        maxThreadDepth,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	replyCount, err := cfg.DbQueries.CountVisibleChirpReplies(r.Context(), uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	root := &threadChirpResponse{
+		ID:         chirp.ID,
+		Body:       chirp.Body,
+		CreatedAt:  chirp.CreatedAt,
+		UpdatedAt:  chirp.UpdatedAt,
+		UserID:     chirp.UserID,
+		Edited:     chirp.EditedAt.Valid,
+		ParentID:   chirp.ParentID,
+		Deleted:    chirp.DeletedAt.Valid,
+		ReplyCount: replyCount,
+	}
+	threadRes := threadResponse{Ancestors: make([]*threadChirpResponse, 0, len(ancestors)), Chirp: root}
+	// Descendants come back breadth first, so every parent is already in the
+	// map by the time one of its replies shows up.
+	nodes := map[uuid.UUID]*threadChirpResponse{root.ID: root}
+	replies := 0
+	for _, d := range descendants {
+		// The reply past the page only shows there is a next one; it and
+		// its own replies are left out.
+		if d.Depth == 1 {
+			if replies++; replies > int(page.Limit) {
+				continue
+			}
+			cursor := encodeCursor(d.CreatedAt, d.ID)
+			threadRes.NextCursor = &cursor
+		}
+		node := &threadChirpResponse{
+			ID:         d.ID,
+			Body:       d.Body,
+			CreatedAt:  d.CreatedAt,
+			UpdatedAt:  d.UpdatedAt,
+			UserID:     d.UserID,
+			Edited:     d.EditedAt.Valid,
+			ParentID:   d.ParentID,
+			Deleted:    d.DeletedAt.Valid,
+			ReplyCount: d.ReplyCount,
+		}
+		nodes[d.ID] = node
+		if parent, ok := nodes[d.ParentID.UUID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+	if replies <= int(page.Limit) {
+		threadRes.NextCursor = nil
+	}
+	for _, a := range ancestors {
+		threadRes.Ancestors = append(threadRes.Ancestors, &threadChirpResponse{
+			ID:         a.ID,
+			Body:       a.Body,
+			CreatedAt:  a.CreatedAt,
+			UpdatedAt:  a.UpdatedAt,
+			UserID:     a.UserID,
+			Edited:     a.EditedAt.Valid,
+			ParentID:   a.ParentID,
+			Deleted:    a.DeletedAt.Valid,
+			ReplyCount: a.ReplyCount,
+		})
+	}
+	utils.RespondWithJson(w, http.StatusOK, threadRes)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+)
+
+// throttlePolicy decides how long a key is locked out after a failure. The
+// first few failures are free, then the delay doubles with every failure
+// until the lockout threshold, which locks the key for the full lockout.
+type throttlePolicy struct {
+	scope        string
+	freeFailures int32
+	lockoutAfter int32
+	baseDelay    time.Duration
+	lockout      time.Duration
+	// Failures older than this are forgotten.
+	resetAfter time.Duration
+}
+
+var (
+	accountThrottle = throttlePolicy{
+		scope:        "account",
+		freeFailures: 3,
+		lockoutAfter: 10,
+		baseDelay:    time.Second,
+		lockout:      15 * time.Minute,
+		resetAfter:   time.Hour,
+	}
+	// Many people can share an address, so an IP gets more slack than an
+	// account.
+	ipThrottle = throttlePolicy{
+		scope:        "ip",
+		freeFailures: 20,
+		lockoutAfter: 100,
+		baseDelay:    time.Second,
+		lockout:      time.Hour,
+		resetAfter:   time.Hour,
+	}
+	mfaThrottle = throttlePolicy{
+		scope:        "mfa",
+		freeFailures: 3,
+		lockoutAfter: 10,
+		baseDelay:    time.Second,
+		lockout:      15 * time.Minute,
+		resetAfter:   time.Hour,
+	}
+)
+
+type throttleKey struct {
+	policy throttlePolicy
+	key    string
+}
+
+func (p throttlePolicy) delay(failures int32) time.Duration {
+	if failures >= p.lockoutAfter {
+		return p.lockout
+	}
+	if failures <= p.freeFailures {
+		return 0
+	}
+	delay := p.baseDelay * time.Duration(math.Pow(2, float64(failures-p.freeFailures-1)))
+	return min(delay, p.lockout)
+}
+
+// reserveAttempt counts an attempt against key before it is checked, and
+// locks key for as long as the policy wants should the attempt fail. The row
+// stays locked until both are done, so a burst of concurrent guesses can't
+// all get in before the lockout. It returns how much longer key is locked
+// out, zero if the attempt may go ahead; a refused attempt isn't counted.
+func (cfg *Apiconfig) reserveAttempt(ctx context.Context, p throttlePolicy, key string) (time.Duration, error) {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	attempt, err := qtx.ReserveLoginAttempt(ctx, database.ReserveLoginAttemptParams{
+		Scope:       p.scope,
+		Key:         key,
+		ResetBefore: time.Now().Add(-p.resetAfter),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if attempt.LockedSeconds > 0 {
+		return time.Duration(attempt.LockedSeconds * float64(time.Second)), nil
+	}
+	if delay := p.delay(attempt.Failures); delay > 0 {
+		err := qtx.LockLogin(ctx, database.LockLoginParams{DelayMs: delay.Milliseconds(), Scope: p.scope, Key: key})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return 0, tx.Commit()
+}
+
+// releaseAttempt takes back an attempt reserved with reserveAttempt that
+// succeeded, for throttles where one success shouldn't wipe out every
+// failure.
+func (cfg *Apiconfig) releaseAttempt(ctx context.Context, p throttlePolicy, key string) error {
+	return cfg.DbQueries.ReleaseLoginAttempt(ctx, database.ReleaseLoginAttemptParams{Scope: p.scope, Key: key})
+}
+
+func (cfg *Apiconfig) clearFailures(ctx context.Context, p throttlePolicy, key string) error {
+	return cfg.DbQueries.ClearLoginFailures(ctx, database.ClearLoginFailuresParams{Scope: p.scope, Key: key})
+}
+
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+}
+
+func respondTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	setRetryAfter(w, wait)
+	utils.RespondWithError(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+}
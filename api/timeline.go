@@ -0,0 +1,42 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+// GetTimeline returns the caller's own chirps together with the chirps of
+// everyone they follow, newest first.
+func (cfg *Apiconfig) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	userId, ok := cfg.authenticate(w, r, scopeChirpsRead)
+	if !ok {
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	chirps, err := cfg.DbQueries.ListTimelineChirps(r.Context(), database.ListTimelineChirpsParams{
+		UserID:          userId,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	timelineRes := newChirpsPage(chirps, page.Limit)
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, timelineRes.Chirps); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, timelineRes)
+}
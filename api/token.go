@@ -0,0 +1,281 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+// Scopes a personal access token can carry. Tokens from a login have all
+// of them.
+const (
+	scopeChirpsRead   = "chirps:read"
+	scopeChirpsWrite  = "chirps:write"
+	scopeProfileWrite = "profile:write"
+	scopeFollowsWrite = "follows:write"
+)
+
+var allScopes = []string{scopeChirpsRead, scopeChirpsWrite, scopeProfileWrite, scopeFollowsWrite}
+
+const maxTokenNameLength = 100
+
+var (
+	errMissingScope = errors.New("token lacks the required scope")
+	errInvalidToken = errors.New("invalid token")
+)
+
+type (
+	tokenRequest struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		// Zero means the token never expires.
+		ExpiresInDays int `json:"expires_in_days"`
+	}
+	tokenResponse struct {
+		ID         uuid.UUID  `json:"id"`
+		Name       string     `json:"name"`
+		Scopes     []string   `json:"scopes"`
+		CreatedAt  time.Time  `json:"created_at"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		// Only set once, in the response to creating the token.
+		Token string `json:"token,omitempty"`
+	}
+)
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func newTokenResponse(pat database.PersonalAccessToken) tokenResponse {
+	return tokenResponse{
+		ID:         pat.ID,
+		Name:       pat.Name,
+		Scopes:     pat.Scopes,
+		CreatedAt:  pat.CreatedAt,
+		ExpiresAt:  nullTimePtr(pat.ExpiresAt),
+		LastUsedAt: nullTimePtr(pat.LastUsedAt),
+	}
+}
+
+// sessionRevoked reports whether the session an access token was issued
+// for has been logged out, which ends the token along with it.
+func (cfg *Apiconfig) sessionRevoked(ctx context.Context, sessionId uuid.UUID) (bool, error) {
+	if sessionId == uuid.Nil {
+		return false, nil
+	}
+	active, err := cfg.DbQueries.SessionActive(ctx, sessionId)
+	return !active, err
+}
+
+// validateToken resolves a bearer token to its user. Access tokens from a
+// login may do anything, personal access tokens only what their scopes allow.
+func (cfg *Apiconfig) validateToken(ctx context.Context, token, scope string) (uuid.UUID, error) {
+	if !auth.IsPersonalAccessToken(token) {
+		userId, sessionId, err := auth.ValidateSessionJWT(token, cfg.Keys)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		revoked, err := cfg.sessionRevoked(ctx, sessionId)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if revoked {
+			return uuid.Nil, errInvalidToken
+		}
+		return userId, nil
+	}
+	lookupId, hash, err := auth.SplitPersonalAccessToken(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	pat, err := cfg.DbQueries.GetPersonalAccessToken(ctx, lookupId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, errInvalidToken
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(pat.TokenHash)) != 1 ||
+		pat.RevokedAt.Valid ||
+		(pat.ExpiresAt.Valid && pat.ExpiresAt.Time.Before(time.Now())) {
+		return uuid.Nil, errInvalidToken
+	}
+	if !slices.Contains(pat.Scopes, scope) {
+		return uuid.Nil, errMissingScope
+	}
+	if err := cfg.DbQueries.TouchPersonalAccessToken(ctx, pat.ID); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+	}
+	return pat.UserID, nil
+}
+
+// authenticate answers 401, or 403 for a token without the needed scope,
+// and returns false unless the request carries a valid token. It also
+// enforces the API rate limit of the user's plan.
+func (cfg *Apiconfig) authenticate(w http.ResponseWriter, r *http.Request, scope string) (uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "please autenticate yourself")
+		return uuid.Nil, false
+	}
+	userId, err := cfg.validateToken(r.Context(), token, scope)
+	if errors.Is(err, errMissingScope) {
+		utils.RespondWithError(w, http.StatusForbidden, "your token lacks the "+scope+" scope")
+		return uuid.Nil, false
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "your token is invalid, get a new one")
+		return uuid.Nil, false
+	}
+	if !cfg.enforceRateLimit(w, r, userId) {
+		return uuid.Nil, false
+	}
+	return userId, true
+}
+
+// authenticateSession is authenticate for account settings, which only an
+// access token from a login may change, never a personal access token. The
+// same rate limit applies.
+func (cfg *Apiconfig) authenticateSession(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "please autenticate yourself")
+		return uuid.Nil, uuid.Nil, false
+	}
+	userId, sessionId, err := auth.ValidateSessionJWT(token, cfg.Keys)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "your token is invalid, get a new one")
+		return uuid.Nil, uuid.Nil, false
+	}
+	revoked, err := cfg.sessionRevoked(r.Context(), sessionId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if revoked {
+		utils.RespondWithError(w, http.StatusUnauthorized, "your token is invalid, get a new one")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !cfg.enforceRateLimit(w, r, userId) {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userId, sessionId, true
+}
+
+func (cfg *Apiconfig) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxTokenNameLength {
+		utils.RespondWithError(w, http.StatusBadRequest, "name must be 1 to 100 characters")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "a token needs at least one scope")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(allScopes, scope) {
+			utils.RespondWithError(w, http.StatusBadRequest, "unknown scope: "+scope)
+			return
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "expires_in_days can't be negative")
+		return
+	}
+	var expiresAt sql.NullTime
+	if req.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, req.ExpiresInDays), Valid: true}
+	}
+
+	token, lookupId, hash, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		log.Printf("Error has occurred creating the personal access token. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	slices.Sort(req.Scopes)
+	pat, err := cfg.DbQueries.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		UserID:    userId,
+		Name:      req.Name,
+		LookupID:  lookupId,
+		TokenHash: hash,
+		Scopes:    slices.Compact(req.Scopes),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	res := newTokenResponse(pat)
+	res.Token = token
+	utils.RespondWithJson(w, http.StatusCreated, res)
+}
+
+func (cfg *Apiconfig) GetTokens(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	pats, err := cfg.DbQueries.ListPersonalAccessTokens(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	res := make([]tokenResponse, 0, len(pats))
+	for _, pat := range pats {
+		res = append(res, newTokenResponse(pat))
+	}
+	utils.RespondWithJson(w, http.StatusOK, res)
+}
+
+func (cfg *Apiconfig) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	tokenId, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid token id")
+		return
+	}
+	revoked, err := cfg.DbQueries.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{ID: tokenId, UserID: userId})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if revoked == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "token not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,351 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	totpIssuer         = "Chirpy"
+	recoveryCodeCount  = 10
+	mfaChallengeExpiry = 5 * time.Minute
+)
+
+type (
+	totpEnrollResponse struct {
+		Secret        string   `json:"secret"`
+		OtpauthURI    string   `json:"otpauth_uri"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	totpCodeRequest struct {
+		Code string `json:"code"`
+	}
+	disableTOTPRequest struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	mfaChallengeResponse struct {
+		MFARequired  bool   `json:"mfa_required"`
+		MFAChallenge string `json:"mfa_challenge"`
+	}
+	loginTwoFactorRequest struct {
+		MFAChallenge string `json:"mfa_challenge"`
+		Code         string `json:"code"`
+	}
+)
+
+// confirmedTOTP returns the user's TOTP settings if two-factor authentication
+// is switched on for them.
+func (cfg *Apiconfig) confirmedTOTP(ctx context.Context, userId uuid.UUID) (database.UserTotp, bool, error) {
+	totp, err := cfg.DbQueries.GetUserTOTP(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return totp, false, nil
+	}
+	if err != nil {
+		return totp, false, err
+	}
+	return totp, totp.ConfirmedAt.Valid, nil
+}
+
+// checkSecondFactor accepts either a current TOTP code or one of the
+// recovery codes, and burns whichever was used.
+func (cfg *Apiconfig) checkSecondFactor(ctx context.Context, totp database.UserTotp, code string) (bool, error) {
+	if step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now()); ok {
+		rows, err := cfg.DbQueries.UseTOTPStep(ctx, database.UseTOTPStepParams{UserID: totp.UserID, LastUsedStep: step})
+		return rows > 0, err
+	}
+	rows, err := cfg.DbQueries.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   totp.UserID,
+		CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+	})
+	return rows > 0, err
+}
+
+// EnrollTOTP starts setting up two-factor authentication. It stays off until
+// the user proves their app works through ConfirmTOTP.
+func (cfg *Apiconfig) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	user, err := cfg.DbQueries.GetUserById(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	_, enabled, err := cfg.confirmedTOTP(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if enabled {
+		utils.RespondWithError(w, http.StatusConflict, "two-factor authentication is already on")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Error has occurred generating the totp secret. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Printf("Error has occurred generating recovery codes. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.UpsertUserTOTP(r.Context(), database.UpsertUserTOTPParams{UserID: userId, Secret: secret}); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := qtx.DeleteRecoveryCodes(r.Context(), userId); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	for _, code := range codes {
+		err := qtx.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+			UserID:   userId,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusCreated, totpEnrollResponse{
+		Secret:        secret,
+		OtpauthURI:    auth.TOTPURI(totpIssuer, user.Email, secret),
+		RecoveryCodes: codes,
+	})
+}
+
+func (cfg *Apiconfig) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	var req totpCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	totp, err := cfg.DbQueries.GetUserTOTP(r.Context(), userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "start the two-factor setup first")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if totp.ConfirmedAt.Valid {
+		utils.RespondWithError(w, http.StatusConflict, "two-factor authentication is already on")
+		return
+	}
+	// Recovery codes don't count here, the point is checking the app works.
+	step, ok := auth.ValidateTOTP(totp.Secret, req.Code, time.Now())
+	if !ok {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid code")
+		return
+	}
+	if _, err := cfg.DbQueries.UseTOTPStep(r.Context(), database.UseTOTPStepParams{UserID: userId, LastUsedStep: step}); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := cfg.DbQueries.ConfirmUserTOTP(r.Context(), userId); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DisableTOTP turns two-factor authentication off. Both the password and a
+// second factor are required, a stolen access token alone isn't enough.
+func (cfg *Apiconfig) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
+		return
+	}
+	var req disableTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	user, err := cfg.DbQueries.GetUserById(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// Guesses here count against the same throttles as logging in, or a
+	// stolen access token would make a way around them.
+	accountKey := strings.ToLower(user.Email)
+	wait, err := cfg.reserveAttempt(r.Context(), accountThrottle, accountKey)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(w, wait)
+		return
+	}
+	isMatch, err := auth.CheckPasswordHash(req.Password, user.Password)
+	if err != nil {
+		log.Printf("Error has occurred comparing hashed password. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !isMatch {
+		utils.RespondWithError(w, http.StatusUnauthorized, "password incorrect.")
+		return
+	}
+	if err := cfg.clearFailures(r.Context(), accountThrottle, accountKey); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+	}
+	totp, enabled, err := cfg.confirmedTOTP(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if enabled {
+		wait, err := cfg.reserveAttempt(r.Context(), mfaThrottle, userId.String())
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if wait > 0 {
+			respondTooManyAttempts(w, wait)
+			return
+		}
+		ok, err := cfg.checkSecondFactor(r.Context(), totp, req.Code)
+		if err != nil {
+			log.Printf("DB error has occurred: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		if !ok {
+			utils.RespondWithError(w, http.StatusUnauthorized, "invalid code")
+			return
+		}
+		if err := cfg.clearFailures(r.Context(), mfaThrottle, userId.String()); err != nil {
+			log.Printf("DB error has occurred: %v", err)
+		}
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.DeleteUserTOTP(r.Context(), userId); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := qtx.DeleteRecoveryCodes(r.Context(), userId); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LoginTwoFactor finishes a login that Login answered with an MFA challenge.
+func (cfg *Apiconfig) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req loginTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	userId, err := auth.ValidateMFAChallenge(req.MFAChallenge, cfg.Keys)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "your challenge is invalid, log in again")
+		return
+	}
+	totp, enabled, err := cfg.confirmedTOTP(r.Context(), userId)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !enabled {
+		utils.RespondWithError(w, http.StatusUnauthorized, "your challenge is invalid, log in again")
+		return
+	}
+	// A challenge is good for many guesses, without a limit six digits
+	// would not take long to brute force.
+	wait, err := cfg.reserveAttempt(r.Context(), mfaThrottle, userId.String())
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if wait > 0 {
+		respondTooManyAttempts(w, wait)
+		return
+	}
+	ok, err := cfg.checkSecondFactor(r.Context(), totp, req.Code)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "invalid code")
+		return
+	}
+	if err := cfg.clearFailures(r.Context(), mfaThrottle, userId.String()); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+	}
+	cfg.respondWithTokens(w, r, userId)
+}
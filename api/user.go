@@ -1,7 +1,10 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -10,22 +13,48 @@ import (
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
 	"github.com/Israel-Andrade-P/Chirpy.git/utils"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type (
 	userRequest struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 	UserResponse struct {
-		ID          uuid.UUID `json:"id"`
-		Email       string    `json:"email"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
-		IsChirpyRed bool      `josn:"is_chirpy_red"`
+		ID            uuid.UUID       `json:"id"`
+		Email         string          `json:"email"`
+		CreatedAt     time.Time       `json:"created_at"`
+		UpdatedAt     time.Time       `json:"updated_at"`
+		IsChirpyRed   bool            `josn:"is_chirpy_red"`
+		EmailVerified bool            `json:"email_verified"`
+		Handle        string          `json:"handle"`
+		DisplayName   string          `json:"display_name"`
+		Bio           string          `json:"bio"`
+		Location      string          `json:"location"`
+		Website       string          `json:"website"`
+		Avatar        *avatarResponse `json:"avatar"`
 	}
 )
 
+func (cfg *Apiconfig) newUserResponse(user database.User) UserResponse {
+	return UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		IsChirpyRed:   user.IsChirpyRed,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		Handle:        user.Handle,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Location:      user.Location,
+		Website:       user.Website,
+		Avatar:        cfg.newAvatarResponse(user.AvatarID),
+	}
+}
+
 func (cfg *Apiconfig) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	var registerReq userRequest
 	if err := json.NewDecoder(r.Body).Decode(&registerReq); err != nil {
@@ -33,6 +62,10 @@ func (cfg *Apiconfig) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
+	if !validEmail(registerReq.Email) {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid email")
+		return
+	}
 	hashedPwd, err := auth.HashPassword(registerReq.Password)
 	if err != nil {
 		log.Printf("Error has occurred hashing password. ERR: %v\n", err)
@@ -40,14 +73,43 @@ func (cfg *Apiconfig) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.DbQueries.CreateUser(r.Context(), database.CreateUserParams{Email: registerReq.Email, Password: hashedPwd})
+	handle := utils.NormalizeHandle(registerReq.Handle)
+	if registerReq.Handle == "" {
+		handle, err = generateHandle()
+		if err != nil {
+			log.Printf("Error has occurred generating a handle. ERR: %v\n", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+	if handle == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid handle")
+		return
+	}
+
+	user, err := cfg.DbQueries.CreateUser(r.Context(), database.CreateUserParams{Email: registerReq.Email, Password: hashedPwd, Handle: handle})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "users_handle_key" {
+		utils.RespondWithError(w, http.StatusConflict, "handle already taken")
+		return
+	}
 	if err != nil {
 		log.Printf("DB error has occurred: %v", err)
 		utils.RespondWithError(w, http.StatusBadRequest, "invalid email")
 		return
 	}
-	u := UserResponse{ID: user.ID, Email: user.Email, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, IsChirpyRed: user.IsChirpyRed}
-	utils.RespondWithJson(w, http.StatusCreated, u)
+	cfg.mailVerification(user)
+	utils.RespondWithJson(w, http.StatusCreated, cfg.newUserResponse(user))
+}
+
+// generateHandle makes up a placeholder handle for users who registered
+// without picking one.
+func generateHandle() (string, error) {
+	bytes := make([]byte, 5)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "user_" + hex.EncodeToString(bytes), nil
 }
 
 func (cfg *Apiconfig) DeleteAllUsers(w http.ResponseWriter, r *http.Request) {
@@ -66,14 +128,8 @@ func (cfg *Apiconfig) DeleteAllUsers(w http.ResponseWriter, r *http.Request) {
 }
 
 func (cfg *Apiconfig) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "please autenticate yourself")
-		return
-	}
-	userId, err := auth.ValidateJWT(token, cfg.Secret)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "your token is invalid, get a new one")
+	userId, _, ok := cfg.authenticateSession(w, r)
+	if !ok {
 		return
 	}
 	var req userRequest
@@ -82,6 +138,10 @@ func (cfg *Apiconfig) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
+	if !validEmail(req.Email) {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid email")
+		return
+	}
 	hashedPwd, err := auth.HashPassword(req.Password)
 	if err != nil {
 		log.Printf("Error has occurred hashing password. ERR: %v\n", err)
@@ -95,11 +155,10 @@ func (cfg *Apiconfig) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusBadRequest, "invalid email")
 		return
 	}
-	utils.RespondWithJson(w, http.StatusOK, UserResponse{
-		ID:          updatedUser.ID,
-		Email:       updatedUser.Email,
-		CreatedAt:   updatedUser.CreatedAt,
-		UpdatedAt:   updatedUser.UpdatedAt,
-		IsChirpyRed: updatedUser.IsChirpyRed,
-	})
+	// Changing the email drops the verification, the new address has to be
+	// confirmed again.
+	if !updatedUser.EmailVerifiedAt.Valid {
+		cfg.mailVerification(updatedUser)
+	}
+	utils.RespondWithJson(w, http.StatusOK, cfg.newUserResponse(updatedUser))
 }
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/mailer"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+)
+
+const verificationTokenTTL = 24 * time.Hour
+
+type (
+	verifyEmailRequest struct {
+		Token string `json:"token"`
+	}
+	resendVerificationRequest struct {
+		Email string `json:"email"`
+	}
+)
+
+// validEmail only accepts a bare address like "jane@example.com", not a
+// display name form or anything net/mail would have to rewrite.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email && strings.Contains(email, "@")
+}
+
+// sendVerificationEmail issues a fresh verification token for the user's
+// current email and mails the link out.
+func (cfg *Apiconfig) sendVerificationEmail(ctx context.Context, user database.User) error {
+	token, hash, err := auth.MakeSignedToken(cfg.Secret)
+	if err != nil {
+		return err
+	}
+	err = cfg.DbQueries.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: hash,
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(verificationTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/app/verify.html?token=%s", cfg.BaseURL, url.QueryEscape(token))
+	return cfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Chirpy email",
+		Body:    fmt.Sprintf("Hi @%s,\n\nConfirm your email address by following this link:\n\n%s\n\nThe link expires in 24 hours. If you didn't sign up for Chirpy you can ignore this email.\n", user.Handle, link),
+	})
+}
+
+// mailVerification sends the verification email in the background, so
+// neither a slow mail server nor whether the account exists shows in how
+// long a request takes. A failed email can be retried through the resend
+// endpoint.
+func (cfg *Apiconfig) mailVerification(user database.User) {
+	go func() {
+		if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+			log.Printf("Error has occurred sending verification email. ERR: %v", err)
+		}
+	}()
+}
+
+// requireVerified answers 403 and returns false when unverified accounts
+// are blocked and the user hasn't verified their email yet.
+func (cfg *Apiconfig) requireVerified(w http.ResponseWriter, user database.User) bool {
+	if cfg.RequireVerifiedEmail && !user.EmailVerifiedAt.Valid {
+		utils.RespondWithError(w, http.StatusForbidden, "please verify your email first")
+		return false
+	}
+	return true
+}
+
+func (cfg *Apiconfig) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	hash, err := auth.ValidateSignedToken(req.Token, cfg.Secret)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	verification, err := cfg.DbQueries.GetEmailVerificationToken(r.Context(), hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if verification.UsedAt.Valid || time.Now().After(verification.ExpiresAt) {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	// Claiming the token in the same statement that checks used_at keeps two
+	// concurrent requests from both using it.
+	claimed, err := qtx.UseEmailVerificationToken(r.Context(), hash)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if claimed == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	// A token only vouches for the address it was sent to, so it is useless
+	// once the user has changed their email.
+	verified, err := qtx.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{ID: verification.UserID, Email: verification.Email})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if verified == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification mails a new verification link. It answers 202 whether
+// or not the email belongs to an account so it can't be used to find out
+// who is registered.
+func (cfg *Apiconfig) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req resendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	user, err := cfg.DbQueries.GetUserByEmail(r.Context(), req.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("DB error has occurred: %v", err)
+	}
+	if err == nil && !user.EmailVerifiedAt.Valid {
+		cfg.mailVerification(user)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
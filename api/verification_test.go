@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/mailer"
+	"github.com/google/uuid"
+)
+
+// recordingDB stands in for the database in tests that only write to it.
+// It keeps the arguments of every statement it is asked to run.
+type recordingDB struct {
+	execs [][]any
+}
+
+func (db *recordingDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	db.execs = append(db.execs, args)
+	return driverResult(1), nil
+}
+
+func (db *recordingDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (db *recordingDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (db *recordingDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+type driverResult int64
+
+func (r driverResult) LastInsertId() (int64, error) { return 0, nil }
+func (r driverResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+func TestSendVerificationEmail(t *testing.T) {
+	db := &recordingDB{}
+	mail := &mailer.MemoryMailer{}
+	cfg := &Apiconfig{
+		DbQueries: database.New(db),
+		Mailer:    mail,
+		Secret:    "test secret",
+		BaseURL:   "https://chirpy.example",
+	}
+	user := database.User{ID: uuid.New(), Email: "walt@example.com", Handle: "walt"}
+
+	if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+		t.Fatalf("sendVerificationEmail error: %v", err)
+	}
+	sent := mail.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 email got: %d", len(sent))
+	}
+	if sent[0].To != user.Email {
+		t.Errorf("expected email to: %s got: %s", user.Email, sent[0].To)
+	}
+
+	//the link leads to the page that verifies the token
+	link, err := url.Parse(linkPattern.FindString(sent[0].Body))
+	if err != nil {
+		t.Fatalf("no link in email: %q", sent[0].Body)
+	}
+	if got := link.Scheme + "://" + link.Host + link.Path; got != "https://chirpy.example/app/verify.html" {
+		t.Errorf("expected link to the verify page got: %s", got)
+	}
+	token := link.Query().Get("token")
+	hash, err := auth.ValidateSignedToken(token, cfg.Secret)
+	if err != nil {
+		t.Fatalf("token in link is invalid: %v", err)
+	}
+
+	//only the hash of the token is stored, for the address it was sent to
+	if len(db.execs) != 1 {
+		t.Fatalf("expected 1 statement got: %d", len(db.execs))
+	}
+	args := db.execs[0]
+	if args[0] != hash {
+		t.Errorf("expected stored hash: %s got: %v", hash, args[0])
+	}
+	if strings.Contains(args[0].(string), token) {
+		t.Errorf("token stored in the clear")
+	}
+	if args[1] != user.ID || args[2] != user.Email {
+		t.Errorf("expected token for: %s %s got: %v %v", user.ID, user.Email, args[1], args[2])
+	}
+}
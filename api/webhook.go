@@ -1,56 +1,201 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/auth"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
 	"github.com/Israel-Andrade-P/Chirpy.git/utils"
 	"github.com/google/uuid"
 )
 
+const (
+	maxWebhookBodySize = 1 << 20
+	// Polka's timestamp may be this far off from our clock. Anything older
+	// is treated as a replay.
+	webhookTolerance = 5 * time.Minute
+)
+
+// Processing status of a stored webhook event.
+const (
+	webhookPending   = "pending"
+	webhookProcessed = "processed"
+	webhookFailed    = "failed"
+	webhookIgnored   = "ignored"
+)
+
 type (
 	webHookRequest struct {
+		ID    string   `json:"id"`
 		Event string   `json:"event"`
 		Data  userInfo `json:"data"`
 	}
 	userInfo struct {
 		UserId string `json:"user_id"`
+		// Plan and CurrentPeriodEnd come with the subscription events. Older
+		// deliveries leave them out.
+		Plan             string     `json:"plan"`
+		CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	}
+	// webhookError is a reason to refuse an event, along with the status
+	// Polka gets back for it.
+	webhookError struct {
+		status  int
+		message string
 	}
 )
 
+func (e *webhookError) Error() string {
+	return e.message
+}
+
+var errUnknownWebhookEvent = &webhookError{http.StatusNotFound, "not a valid event"}
+
 func (cfg *Apiconfig) UpdateChirpRedStatus(w http.ResponseWriter, r *http.Request) {
-	apiKey, err := auth.GetApiKey(r.Header)
+	// The signature covers the exact bytes Polka sent, so read them before
+	// decoding.
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
 	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "authentication process failed")
+		utils.RespondWithError(w, http.StatusBadRequest, "couldn't read request body")
 		return
 	}
-	if !strings.EqualFold(apiKey, cfg.PolkaKey) {
-		utils.RespondWithError(w, http.StatusUnauthorized, "invalid api key")
+	err = auth.VerifyWebhook(r.Header.Get("Polka-Signature"), r.Header.Get("Polka-Timestamp"), body, cfg.PolkaSecrets, webhookTolerance, time.Now())
+	if err != nil {
+		log.Printf("Rejected Polka webhook. ERR: %v", err)
+		utils.RespondWithError(w, http.StatusUnauthorized, "invalid webhook signature")
 		return
 	}
 
+	// VerifyWebhook already checked that the timestamp parses.
+	sentUnix, _ := strconv.ParseInt(r.Header.Get("Polka-Timestamp"), 10, 64)
+
 	var req webHookRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		log.Printf("Error has occurred decoding request body. ERR: %v\n", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
-	if req.Event != "user.upgraded" {
-		utils.RespondWithError(w, http.StatusNotFound, "not a valid event")
-		return
+	// Without an id from Polka, identical bytes may well be two separate
+	// events, e.g. a user upgrading twice. Only the same signed delivery,
+	// timestamp and all, counts as a duplicate.
+	eventId := req.ID
+	if eventId == "" {
+		sum := sha256.Sum256(append([]byte(r.Header.Get("Polka-Timestamp")+"."), body...))
+		eventId = "sha256:" + hex.EncodeToString(sum[:])
 	}
-	userId, err := uuid.Parse(req.Data.UserId)
+	err = cfg.DbQueries.RecordWebhookEvent(r.Context(), database.RecordWebhookEventParams{
+		EventID:   eventId,
+		EventType: req.Event,
+		Payload:   body,
+		SentAt:    time.Unix(sentUnix, 0).UTC(),
+	})
 	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "not a valid user id")
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	err = cfg.processWebhookEvent(r.Context(), eventId)
+	var whErr *webhookError
+	if errors.As(err, &whErr) {
+		utils.RespondWithError(w, whErr.status, whErr.message)
 		return
 	}
-	err = cfg.DbQueries.UpdateChirpyRed(r.Context(), userId)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "user doesn't exist")
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// processWebhookEvent applies a stored event unless that already happened,
+// and records the outcome on it. A *webhookError means the event itself was
+// refused, any other error is ours.
+func (cfg *Apiconfig) processWebhookEvent(ctx context.Context, eventId string) error {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	event, err := qtx.LockWebhookEvent(ctx, eventId)
+	if err != nil {
+		return err
+	}
+	switch event.Status {
+	case webhookProcessed:
+		return nil
+	case webhookIgnored:
+		return errUnknownWebhookEvent
+	}
+
+	applyErr := applyWebhookEvent(ctx, qtx, event)
+	status := webhookProcessed
+	if applyErr == errUnknownWebhookEvent {
+		status = webhookIgnored
+	} else if applyErr != nil {
+		// Whatever the event changed is rolled back, only the failure is kept.
+		tx.Rollback()
+		err := cfg.DbQueries.FailWebhookEvent(ctx, database.FailWebhookEventParams{
+			ID:    event.ID,
+			Error: sql.NullString{String: applyErr.Error(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return applyErr
+	}
+	if err := qtx.FinishWebhookEvent(ctx, database.FinishWebhookEventParams{ID: event.ID, Status: status}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return applyErr
+}
+
+func applyWebhookEvent(ctx context.Context, q *database.Queries, event database.WebhookEvent) error {
+	var req webHookRequest
+	if err := json.Unmarshal(event.Payload, &req); err != nil {
+		return err
+	}
+	var apply func(context.Context, *database.Queries, uuid.UUID, userInfo, time.Time) error
+	switch req.Event {
+	case "user.upgraded", "subscription.renewed":
+		apply = activateSubscription
+	case "payment.failed":
+		apply = failSubscriptionPayment
+	case "user.downgraded":
+		apply = cancelSubscription
+	default:
+		return errUnknownWebhookEvent
+	}
+	userId, err := uuid.Parse(req.Data.UserId)
+	if err != nil {
+		return &webhookError{http.StatusBadRequest, "not a valid user id"}
+	}
+	// Polka may deliver events out of order, e.g. a renewal after the
+	// downgrade that followed it. One older than what the subscription
+	// already reflects changes nothing.
+	lastEventAt, err := q.LockSubscription(ctx, userId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if lastEventAt.Valid && lastEventAt.Time.After(event.SentAt) {
+		log.Printf("Skipped stale Polka event %s for user %s", event.EventID, userId)
+		return nil
+	}
+	return apply(ctx, q, userId, req.Data, event.SentAt)
+}
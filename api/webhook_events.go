@@ -0,0 +1,136 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/utils"
+	"github.com/google/uuid"
+)
+
+var webhookStatuses = []string{webhookPending, webhookProcessed, webhookFailed, webhookIgnored}
+
+type (
+	webhookEventResponse struct {
+		ID          uuid.UUID       `json:"id"`
+		EventID     string          `json:"event_id"`
+		EventType   string          `json:"event_type"`
+		Payload     json.RawMessage `json:"payload"`
+		ReceivedAt  time.Time       `json:"received_at"`
+		Status      string          `json:"status"`
+		Error       *string         `json:"error"`
+		Attempts    int32           `json:"attempts"`
+		ProcessedAt *time.Time      `json:"processed_at"`
+	}
+	webhookEventsPage struct {
+		Events     []webhookEventResponse `json:"events"`
+		Limit      int32                  `json:"limit"`
+		NextCursor *string                `json:"next_cursor"`
+	}
+)
+
+func newWebhookEventResponse(event database.WebhookEvent) webhookEventResponse {
+	res := webhookEventResponse{
+		ID:          event.ID,
+		EventID:     event.EventID,
+		EventType:   event.EventType,
+		Payload:     event.Payload,
+		ReceivedAt:  event.ReceivedAt,
+		Status:      event.Status,
+		Attempts:    event.Attempts,
+		ProcessedAt: nullTimePtr(event.ProcessedAt),
+	}
+	if event.Error.Valid {
+		res.Error = &event.Error.String
+	}
+	return res
+}
+
+// GetWebhookEvents lists received webhooks newest first, optionally only
+// those with the given ?status=.
+func (cfg *Apiconfig) GetWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+	var status sql.NullString
+	if s := r.URL.Query().Get("status"); s != "" {
+		if !slices.Contains(webhookStatuses, s) {
+			utils.RespondWithError(w, http.StatusBadRequest, "unknown status: "+s)
+			return
+		}
+		status = sql.NullString{String: s, Valid: true}
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	events, err := cfg.DbQueries.ListWebhookEvents(r.Context(), database.ListWebhookEventsParams{
+		Status:           status,
+		CursorReceivedAt: page.CursorCreatedAt,
+		CursorID:         page.CursorID,
+		Limit:            page.Limit + 1,
+	})
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	res := webhookEventsPage{Events: make([]webhookEventResponse, 0, len(events)), Limit: page.Limit}
+	if len(events) > int(page.Limit) {
+		events = events[:page.Limit]
+		last := events[len(events)-1]
+		cursor := encodeCursor(last.ReceivedAt, last.ID)
+		res.NextCursor = &cursor
+	}
+	for _, event := range events {
+		res.Events = append(res.Events, newWebhookEventResponse(event))
+	}
+	utils.RespondWithJson(w, http.StatusOK, res)
+}
+
+// ReplayWebhookEvent applies a failed event again, say once the user it
+// named exists, and answers with the event as it stands afterwards.
+func (cfg *Apiconfig) ReplayWebhookEvent(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "invalid event id")
+		return
+	}
+	event, err := cfg.DbQueries.GetWebhookEvent(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "event not found")
+		return
+	}
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if event.Status != webhookFailed {
+		utils.RespondWithError(w, http.StatusConflict, "only failed events can be replayed")
+		return
+	}
+
+	err = cfg.processWebhookEvent(r.Context(), event.EventID)
+	var whErr *webhookError
+	if err != nil && !errors.As(err, &whErr) {
+		log.Printf("Error has occurred replaying webhook event %s. ERR: %v", event.ID, err)
+	}
+	event, err = cfg.DbQueries.GetWebhookEvent(r.Context(), id)
+	if err != nil {
+		log.Printf("DB error has occurred: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	utils.RespondWithJson(w, http.StatusOK, newWebhookEventResponse(event))
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"testing"
@@ -34,15 +35,28 @@ func TestHashPassword(t *testing.T) {
 	}
 }
 
+func newTestKeySet(t *testing.T) *KeySet {
+	t.Helper()
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey error: %v", err)
+	}
+	keys, err := NewKeySet([]SigningKey{key}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet error: %v", err)
+	}
+	return keys
+}
+
 func TestValidateJWT(t *testing.T) {
 	expectedId := uuid.New()
-	tokenSecret := "mysecret"
+	keys := newTestKeySet(t)
 	expires := time.Second * 10
-	jwtToken, err := MakeJWT(expectedId, tokenSecret, expires)
+	jwtToken, err := MakeJWT(expectedId, keys, expires)
 	if err != nil {
 		t.Fatalf("MakeJWT error: %v", err)
 	}
-	resultId, err := ValidateJWT(jwtToken, tokenSecret)
+	resultId, err := ValidateJWT(jwtToken, keys)
 	if err != nil {
 		t.Fatalf("error occurred during testing: %v", err)
 	}
@@ -50,18 +64,18 @@ func TestValidateJWT(t *testing.T) {
 		t.Errorf("token doesn't belong to logged in user")
 	}
 
-	//wrong secret passed in
-	_, err = ValidateJWT(jwtToken, "wrongsecret")
+	//signed with a key from another set
+	_, err = ValidateJWT(jwtToken, newTestKeySet(t))
 	if err == nil {
 		t.Errorf("token validated even with wrong secret")
 	}
 
 	//testing token expiration
-	expiredJwt, err := MakeJWT(expectedId, tokenSecret, -1*time.Second)
+	expiredJwt, err := MakeJWT(expectedId, keys, -1*time.Second)
 	if err != nil {
 		t.Fatalf("MakeJWT error: %v", err)
 	}
-	_, err = ValidateJWT(expiredJwt, tokenSecret)
+	_, err = ValidateJWT(expiredJwt, keys)
 
 	if err == nil {
 		t.Errorf("expected token to be expired, but got no error")
@@ -138,3 +152,295 @@ func TestGetBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestSignedToken(t *testing.T) {
+	secret := "mysecret"
+	token, hash, err := MakeSignedToken(secret)
+	if err != nil {
+		t.Fatalf("MakeSignedToken error: %v", err)
+	}
+	if strings.Contains(hash, token) || hash == token {
+		t.Errorf("hash shouldn't contain the raw token")
+	}
+	gotHash, err := ValidateSignedToken(token, secret)
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	if gotHash != hash {
+		t.Errorf("expected hash: %s got: %s", hash, gotHash)
+	}
+
+	//wrong secret passed in
+	if _, err := ValidateSignedToken(token, "wrongsecret"); err == nil {
+		t.Errorf("token validated even with wrong secret")
+	}
+	//tampered token
+	if _, err := ValidateSignedToken("x"+token, secret); err == nil {
+		t.Errorf("tampered token validated")
+	}
+	//missing signature
+	id, _, _ := strings.Cut(token, ".")
+	if _, err := ValidateSignedToken(id, secret); err == nil {
+		t.Errorf("token without signature validated")
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B test vectors for SHA1, cut down to 6 digits.
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(c.unix, 0)))
+		if err != nil {
+			t.Fatalf("error occurred during testing: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("at %d expected code: %s got: %s", c.unix, c.want, got)
+		}
+	}
+
+	now := time.Unix(1234567890, 0)
+	if step, ok := ValidateTOTP(secret, "005924", now.Add(totpPeriod*time.Second)); !ok || step != TOTPStep(now) {
+		t.Errorf("code from the previous period should be accepted")
+	}
+	if _, ok := ValidateTOTP(secret, "005924", now.Add(5*totpPeriod*time.Second)); ok {
+		t.Errorf("stale code validated")
+	}
+}
+
+func TestMFAChallenge(t *testing.T) {
+	userId := uuid.New()
+	keys := newTestKeySet(t)
+	challenge, err := MakeMFAChallenge(userId, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	got, err := ValidateMFAChallenge(challenge, keys)
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	if got != userId {
+		t.Errorf("expected id: %v got: %v", userId, got)
+	}
+	//a challenge is no access token
+	if _, err := ValidateJWT(challenge, keys); err == nil {
+		t.Errorf("mfa challenge accepted as access token")
+	}
+}
+
+func TestSplitRefreshToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("MakeRefreshToken error: %v", err)
+	}
+	id, hash, err := SplitRefreshToken(token)
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	if !strings.HasPrefix(token, id) || len(id) != refreshTokenLookupLen {
+		t.Errorf("expected id to be the start of the token, got: %s", id)
+	}
+	if hash != HashToken(token) {
+		t.Errorf("expected hash: %s got: %s", HashToken(token), hash)
+	}
+	//not a refresh token
+	if _, _, err := SplitRefreshToken("not-a-token"); err == nil {
+		t.Errorf("malformed token accepted")
+	}
+}
+
+func TestSessionJWT(t *testing.T) {
+	userId, sessionId := uuid.New(), uuid.New()
+	keys := newTestKeySet(t)
+	token, err := MakeSessionJWT(userId, sessionId, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	gotUser, gotSession, err := ValidateSessionJWT(token, keys)
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	if gotUser != userId || gotSession != sessionId {
+		t.Errorf("expected %v/%v got: %v/%v", userId, sessionId, gotUser, gotSession)
+	}
+	//tokens without a session still validate
+	token, _ = MakeJWT(userId, keys, time.Minute)
+	if _, gotSession, err := ValidateSessionJWT(token, keys); err != nil || gotSession != uuid.Nil {
+		t.Errorf("expected no session, got: %v err: %v", gotSession, err)
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	keys := newTestKeySet(t)
+	userId := uuid.New()
+	oldToken, err := MakeJWT(userId, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT error: %v", err)
+	}
+	next, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey error: %v", err)
+	}
+	retired := keys.Rotate(next)
+	if keys.Active().ID != next.ID {
+		t.Errorf("expected active key: %s got: %s", next.ID, keys.Active().ID)
+	}
+	//tokens of the retired key are still accepted
+	if _, err := ValidateJWT(oldToken, keys); err != nil {
+		t.Errorf("token of retired key rejected: %v", err)
+	}
+	if got := len(keys.JWKS().Keys); got != 2 {
+		t.Errorf("expected 2 keys in jwks, got: %d", got)
+	}
+
+	//until the retention is over
+	retired.RetiredAt = time.Now().Add(-2 * time.Hour)
+	keys, err = NewKeySet([]SigningKey{retired, next}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet error: %v", err)
+	}
+	if _, err := ValidateJWT(oldToken, keys); err == nil {
+		t.Errorf("token of expired key accepted")
+	}
+	if got := len(keys.JWKS().Keys); got != 1 {
+		t.Errorf("expected 1 key in jwks, got: %d", got)
+	}
+}
+
+func TestKeySetReplace(t *testing.T) {
+	keys := newTestKeySet(t)
+	old := keys.Active()
+	oldToken, err := MakeJWT(uuid.New(), keys, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT error: %v", err)
+	}
+	//a set without an active key is refused
+	if err := keys.Replace([]SigningKey{}); err == nil {
+		t.Errorf("expected error replacing with no active key")
+	}
+	if keys.Active().ID != old.ID {
+		t.Errorf("failed replace changed the active key")
+	}
+
+	//another instance rotated the keys
+	next, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey error: %v", err)
+	}
+	old.RetiredAt = time.Now()
+	if err := keys.Replace([]SigningKey{old, next}); err != nil {
+		t.Fatalf("Replace error: %v", err)
+	}
+	if keys.Active().ID != next.ID {
+		t.Errorf("expected active key: %s got: %s", next.ID, keys.Active().ID)
+	}
+	if _, err := ValidateJWT(oldToken, keys); err != nil {
+		t.Errorf("token of retired key rejected: %v", err)
+	}
+}
+
+func TestSealKeySeed(t *testing.T) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey error: %v", err)
+	}
+	sealed, err := SealKeySeed(key.PrivateKey.Seed(), "mysecret")
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	seed, err := OpenKeySeed(sealed, "mysecret")
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	opened, err := NewSigningKey(seed, key.CreatedAt)
+	if err != nil || opened.ID != key.ID {
+		t.Errorf("expected key: %s got: %s err: %v", key.ID, opened.ID, err)
+	}
+	//wrong secret passed in
+	if _, err := OpenKeySeed(sealed, "wrongsecret"); err == nil {
+		t.Errorf("sealed key opened with wrong secret")
+	}
+}
+
+func TestPersonalAccessToken(t *testing.T) {
+	token, lookupId, hash, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatalf("MakePersonalAccessToken error: %v", err)
+	}
+	if !IsPersonalAccessToken(token) {
+		t.Errorf("expected prefix %s, got: %s", PersonalAccessTokenPrefix, token)
+	}
+	gotId, gotHash, err := SplitPersonalAccessToken(token)
+	if err != nil {
+		t.Fatalf("error occurred during testing: %v", err)
+	}
+	if gotId != lookupId || gotHash != hash {
+		t.Errorf("expected %s/%s got: %s/%s", lookupId, hash, gotId, gotHash)
+	}
+	//a refresh token is no personal access token
+	refresh, _ := MakeRefreshToken()
+	if _, _, err := SplitPersonalAccessToken(refresh); err == nil {
+		t.Errorf("refresh token accepted as personal access token")
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"event":"user.upgraded"}`)
+	now := time.Unix(1700000000, 0)
+	timestamp := fmt.Sprint(now.Unix())
+	sig := SignWebhook("current", now, body)
+
+	cases := []struct {
+		name      string
+		signature string
+		timestamp string
+		body      []byte
+		secrets   []string
+		wantErr   error
+	}{
+		{name: "valid", signature: sig, timestamp: timestamp, body: body, secrets: []string{"current"}},
+		{name: "previous secret during rotation", signature: sig, timestamp: timestamp, body: body, secrets: []string{"next", "current"}},
+		{name: "one of several signatures", signature: "v1=00ff, " + sig, timestamp: timestamp, body: body, secrets: []string{"current"}},
+		{name: "wrong secret", signature: sig, timestamp: timestamp, body: body, secrets: []string{"other"}, wantErr: ErrInvalidSignature},
+		{name: "tampered body", signature: sig, timestamp: timestamp, body: []byte(`{"event":"user.downgraded"}`), secrets: []string{"current"}, wantErr: ErrInvalidSignature},
+		{name: "replayed with new timestamp", signature: sig, timestamp: fmt.Sprint(now.Unix() + 1), body: body, secrets: []string{"current"}, wantErr: ErrInvalidSignature},
+		{name: "stale", signature: SignWebhook("current", now.Add(-10*time.Minute), body), timestamp: fmt.Sprint(now.Add(-10 * time.Minute).Unix()), body: body, secrets: []string{"current"}, wantErr: ErrStaleWebhook},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := VerifyWebhook(c.signature, c.timestamp, c.body, c.secrets, 5*time.Minute, now)
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("expected error: %v got: %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMakeWebhookSecret(t *testing.T) {
+	secret, err := MakeWebhookSecret()
+	if err != nil {
+		t.Fatalf("MakeWebhookSecret error: %v", err)
+	}
+	other, err := MakeWebhookSecret()
+	if err != nil {
+		t.Fatalf("MakeWebhookSecret error: %v", err)
+	}
+	if secret == other {
+		t.Fatalf("expected different secrets got: %s twice", secret)
+	}
+	now := time.Now()
+	body := []byte(`{"type":"chirp.created"}`)
+	err = VerifyWebhook(SignWebhook(secret, now, body), fmt.Sprint(now.Unix()), body, []string{secret}, time.Minute, now)
+	if err != nil {
+		t.Fatalf("expected signature to verify got: %v", err)
+	}
+}
@@ -8,42 +8,103 @@ import (
 	"github.com/google/uuid"
 )
 
-func MakeJWT(userId uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+const (
+	accessTokenIssuer = "chirpy"
+	// MFA challenges are signed with the same keys, the different issuer is
+	// what keeps them from being accepted as access tokens.
+	mfaChallengeIssuer = "chirpy-mfa"
+)
+
+// claims adds the session an access token was issued for, so handlers can
+// tell which of a user's sessions is making the request.
+type claims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+}
+
+func MakeJWT(userId uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return makeJWT(accessTokenIssuer, userId, uuid.Nil, keys, expiresIn)
+}
+
+// MakeSessionJWT is MakeJWT for a token that belongs to a login session.
+func MakeSessionJWT(userId, sessionId uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return makeJWT(accessTokenIssuer, userId, sessionId, keys, expiresIn)
+}
+
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	userId, _, err := validateJWT(accessTokenIssuer, tokenString, keys)
+	return userId, err
+}
+
+// ValidateSessionJWT is ValidateJWT that also returns the session id, which
+// is uuid.Nil for tokens not tied to a session.
+func ValidateSessionJWT(tokenString string, keys *KeySet) (uuid.UUID, uuid.UUID, error) {
+	return validateJWT(accessTokenIssuer, tokenString, keys)
+}
+
+// MakeMFAChallenge issues the token a user gets after a correct password when
+// they still have to provide their second factor.
+func MakeMFAChallenge(userId uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return makeJWT(mfaChallengeIssuer, userId, uuid.Nil, keys, expiresIn)
+}
+
+func ValidateMFAChallenge(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	userId, _, err := validateJWT(mfaChallengeIssuer, tokenString, keys)
+	return userId, err
+}
+
+func makeJWT(issuer string, userId, sessionId uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
 	now := jwt.NewNumericDate(time.Now())
-	token := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		jwt.RegisteredClaims{
-			Issuer:    "chirpy",
+	c := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
 			IssuedAt:  now,
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 			Subject:   userId.String(),
 		},
-	)
-	signedToken, err := token.SignedString([]byte(tokenSecret))
+	}
+	if sessionId != uuid.Nil {
+		c.SessionID = sessionId.String()
+	}
+	key := keys.Active()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, c)
+	token.Header["kid"] = key.ID
+	signedToken, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return "", err
 	}
 	return signedToken, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claims := &jwt.RegisteredClaims{}
+func validateJWT(issuer, tokenString string, keys *KeySet) (uuid.UUID, uuid.UUID, error) {
+	c := &claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	token, err := jwt.ParseWithClaims(tokenString, c, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(tokenSecret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.verificationKey(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		return key, nil
+	}, jwt.WithIssuer(issuer), jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}))
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, uuid.Nil, err
 	}
 	if !token.Valid {
-		return uuid.Nil, errors.New("invalid token")
+		return uuid.Nil, uuid.Nil, errors.New("invalid token")
 	}
-	userId, err := uuid.Parse(claims.Subject)
+	userId, err := uuid.Parse(c.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, uuid.Nil, err
+	}
+	sessionId := uuid.Nil
+	if c.SessionID != "" {
+		if sessionId, err = uuid.Parse(c.SessionID); err != nil {
+			return uuid.Nil, uuid.Nil, err
+		}
 	}
-	return userId, nil
+	return userId, sessionId, nil
 }
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SigningKey is an Ed25519 key that signs access tokens. Retired keys no
+// longer sign anything but still verify the tokens they signed before.
+type SigningKey struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+	CreatedAt  time.Time
+	RetiredAt  time.Time
+}
+
+func (k SigningKey) Retired() bool {
+	return !k.RetiredAt.IsZero()
+}
+
+// GenerateSigningKey creates a fresh key. Its id is the RFC 7638 thumbprint
+// of the public key.
+func GenerateSigningKey() (SigningKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return NewSigningKey(priv.Seed(), time.Now())
+}
+
+// NewSigningKey rebuilds a key from its seed, e.g. after loading it.
+func NewSigningKey(seed []byte, createdAt time.Time) (SigningKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return SigningKey{}, errors.New("invalid ed25519 seed")
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	x := base64.RawURLEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+	thumbprint := sha256.Sum256([]byte(`{"crv":"Ed25519","kty":"OKP","x":"` + x + `"}`))
+	return SigningKey{
+		ID:         base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+		PrivateKey: priv,
+		CreatedAt:  createdAt,
+	}, nil
+}
+
+// KeySet holds the key currently signing tokens plus the retired keys whose
+// tokens may still be around. It is safe for concurrent use.
+type KeySet struct {
+	mu     sync.RWMutex
+	active SigningKey
+	keys   map[string]SigningKey
+	// retention is how long a retired key keeps verifying tokens, at least
+	// the lifetime of the longest lived token it could have signed.
+	retention time.Duration
+}
+
+// NewKeySet builds a set from stored keys. The newest key that isn't
+// retired becomes the active one.
+func NewKeySet(keys []SigningKey, retention time.Duration) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]SigningKey), retention: retention}
+	for _, key := range keys {
+		ks.keys[key.ID] = key
+		if !key.Retired() && (ks.active.PrivateKey == nil || key.CreatedAt.After(ks.active.CreatedAt)) {
+			ks.active = key
+		}
+	}
+	if ks.active.PrivateKey == nil {
+		return nil, errors.New("key set has no active key")
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Active() SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.active
+}
+
+// Rotate makes key the active key and retires the previous one. The retired
+// key is returned so it can be stored as such.
+func (ks *KeySet) Rotate(key SigningKey) SigningKey {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	old := ks.active
+	old.RetiredAt = time.Now()
+	ks.keys[old.ID] = old
+	ks.keys[key.ID] = key
+	ks.active = key
+	return old
+}
+
+// Replace swaps the whole set for keys, e.g. after another instance rotated
+// them. The set is left alone when keys has no active key.
+func (ks *KeySet) Replace(keys []SigningKey) error {
+	next, err := NewKeySet(keys, ks.retention)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.active = next.active
+	ks.keys = next.keys
+	return nil
+}
+
+// verificationKey returns the public key for kid, as long as its tokens are
+// still accepted.
+func (ks *KeySet) verificationKey(kid string) (ed25519.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	if !ok || ks.expired(key) {
+		return nil, false
+	}
+	return key.PrivateKey.Public().(ed25519.PublicKey), true
+}
+
+func (ks *KeySet) expired(key SigningKey) bool {
+	return key.Retired() && time.Since(key.RetiredAt) > ks.retention
+}
+
+type (
+	// JWKS is the JSON Web Key Set other services fetch to verify our tokens.
+	JWKS struct {
+		Keys []JWK `json:"keys"`
+	}
+	JWK struct {
+		Kty string `json:"kty"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+	}
+)
+
+// JWKS lists the public half of every key whose tokens are still accepted.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	keys := make([]SigningKey, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		if !ks.expired(key) {
+			keys = append(keys, key)
+		}
+	}
+	// Newest first, so the active key leads.
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key.PrivateKey.Public().(ed25519.PublicKey)),
+			Kid: key.ID,
+			Use: "sig",
+			Alg: "EdDSA",
+		})
+	}
+	return set
+}
+
+// SealKeySeed encrypts a key seed with AES-GCM under a key derived from
+// secret, so private keys aren't stored in the clear.
+func SealKeySeed(seed []byte, secret string) ([]byte, error) {
+	gcm, err := keyCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, seed, nil), nil
+}
+
+func OpenKeySeed(sealed []byte, secret string) ([]byte, error) {
+	gcm, err := keyCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed key too short")
+	}
+	seed, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("opening sealed key: %w", err)
+	}
+	return seed, nil
+}
+
+func keyCipher(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("chirpy signing keys:" + secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+)
+
+// PersonalAccessTokenPrefix marks personal access tokens, which tells them
+// apart from JWTs and makes them easy to spot in leaked code.
+const PersonalAccessTokenPrefix = "chirpy_pat_"
+
+// MakePersonalAccessToken creates a long-lived token for scripts and bots.
+// Only the lookup id and hash are meant to be stored.
+func MakePersonalAccessToken() (token, lookupId, hash string, err error) {
+	random, err := MakeRefreshToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	token = PersonalAccessTokenPrefix + random
+	lookupId, hash, err = SplitPersonalAccessToken(token)
+	return token, lookupId, hash, err
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// SplitPersonalAccessToken is SplitRefreshToken for personal access tokens.
+func SplitPersonalAccessToken(token string) (lookupId, hash string, err error) {
+	random, ok := strings.CutPrefix(token, PersonalAccessTokenPrefix)
+	if !ok {
+		return "", "", errors.New("malformed personal access token")
+	}
+	lookupId, _, err = SplitRefreshToken(random)
+	if err != nil {
+		return "", "", errors.New("malformed personal access token")
+	}
+	return lookupId, HashToken(token), nil
+}
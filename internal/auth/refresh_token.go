@@ -3,8 +3,13 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 )
 
+// refreshTokenLookupLen is how many characters at the start of a refresh
+// token are stored in the clear to find its row.
+const refreshTokenLookupLen = 16
+
 func MakeRefreshToken() (string, error) {
 	bytes := make([]byte, 32)
 	_, err := rand.Read(bytes)
@@ -13,3 +18,15 @@ func MakeRefreshToken() (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
+// SplitRefreshToken returns the lookup id of a refresh token and the digest
+// that is stored for it. Only these two ever go in the database.
+func SplitRefreshToken(token string) (lookupId, hash string, err error) {
+	if len(token) != 64 {
+		return "", "", errors.New("malformed refresh token")
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		return "", "", errors.New("malformed refresh token")
+	}
+	return token[:refreshTokenLookupLen], HashToken(token), nil
+}
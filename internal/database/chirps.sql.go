@@ -12,20 +12,27 @@ import (
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (body, created_at, updated_at, user_id)
+INSERT INTO chirps (body, created_at, updated_at, user_id, parent_id, repost_of_id)
 VALUES (
-    $1, NOW(), NOW(), $2
+    $1, NOW(), NOW(), $2, $3, $4
 )
-RETURNING id, body, created_at, updated_at, user_id
+RETURNING id, body, created_at, updated_at, user_id, search_vector, edited_at, parent_id, deleted_at, repost_of_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	ParentID   uuid.NullUUID
+	RepostOfID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ParentID,
+		arg.RepostOfID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -33,6 +40,11 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
+		&i.SearchVector,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.DeletedAt,
+		&i.RepostOfID,
 	)
 	return i, err
 }
@@ -12,7 +12,7 @@ import (
 )
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, body, created_at, updated_at, user_id FROM chirps WHERE id=$1
+SELECT id, body, created_at, updated_at, user_id, search_vector, edited_at, parent_id, deleted_at, repost_of_id FROM chirps WHERE id=$1
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -24,6 +24,35 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
+		&i.SearchVector,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.DeletedAt,
+		&i.RepostOfID,
+	)
+	return i, err
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, body, created_at, updated_at, user_id, search_vector, edited_at, parent_id, deleted_at, repost_of_id FROM chirps WHERE id = $1 FOR UPDATE
+`
+
+// Locks the chirp until the transaction ends, so concurrent edits each see
+// the version the other one left behind.
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditedAt,
+		&i.ParentID,
+		&i.DeletedAt,
+		&i.RepostOfID,
 	)
 	return i, err
 }
@@ -10,19 +10,25 @@ import (
 )
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens WHERE token=$1
+SELECT created_at, updated_at, user_id, expires_at, revoked_at, family_id, id, token_hash, user_agent, ip, last_used_at, rotated_at FROM refresh_tokens WHERE id=$1
 `
 
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+func (q *Queries) GetRefreshToken(ctx context.Context, id string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, id)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ID,
+		&i.TokenHash,
+		&i.UserAgent,
+		&i.Ip,
+		&i.LastUsedAt,
+		&i.RotatedAt,
 	)
 	return i, err
 }
@@ -12,7 +12,7 @@ import (
 )
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, created_at, updated_at, email, password, is_chirpy_red FROM users WHERE id=$1
+SELECT id, created_at, updated_at, email, password, is_chirpy_red, handle, display_name, bio, location, website, avatar_id, email_verified_at FROM users WHERE id=$1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
@@ -25,6 +25,13 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.Password,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarID,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
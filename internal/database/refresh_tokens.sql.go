@@ -12,23 +12,63 @@ import (
 	"github.com/google/uuid"
 )
 
-const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (id, token_hash, created_at, updated_at, user_id, expires_at, family_id, user_agent, ip, last_used_at)
 VALUES (
-    $1, NOW(), NOW(), $2, $3
+    $1, $2, NOW(), NOW(), $3, $4, $5, $6, $7, NOW()
 )
-RETURNING token
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
+	ID        string
+	TokenHash string
 	UserID    uuid.UUID
 	ExpiresAt time.Time
+	FamilyID  uuid.UUID
+	UserAgent string
+	Ip        string
 }
 
-func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (string, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
-	var token string
-	err := row.Scan(&token)
-	return token, err
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createRefreshToken,
+		arg.ID,
+		arg.TokenHash,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.UserAgent,
+		arg.Ip,
+	)
+	return err
+}
+
+const claimRefreshToken = `-- name: ClaimRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    rotated_at = NOW(),
+    updated_at = NOW(),
+    last_used_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+// Revokes a token that is being exchanged for a new one. Zero rows means it
+// was revoked already, either traded in or logged out.
+func (q *Queries) ClaimRefreshToken(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimRefreshToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeTokenFamily = `-- name: RevokeTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeTokenFamily, familyID)
+	return err
 }
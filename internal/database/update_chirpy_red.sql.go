@@ -11,13 +11,21 @@ import (
 	"github.com/google/uuid"
 )
 
-const updateChirpyRed = `-- name: UpdateChirpyRed :exec
+const updateChirpyRed = `-- name: UpdateChirpyRed :execrows
 UPDATE users
-SET is_chirpy_red = true
+SET is_chirpy_red = $2
 WHERE id = $1
 `
 
-func (q *Queries) UpdateChirpyRed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, updateChirpyRed, id)
-	return err
+type UpdateChirpyRedParams struct {
+	ID          uuid.UUID
+	IsChirpyRed bool
+}
+
+func (q *Queries) UpdateChirpyRed(ctx context.Context, arg UpdateChirpyRedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateChirpyRed, arg.ID, arg.IsChirpyRed)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
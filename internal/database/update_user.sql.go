@@ -13,11 +13,12 @@ import (
 
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET email = $1,
+SET email_verified_at = CASE WHEN email = $1 THEN email_verified_at END,
+    email = $1,
     password = $2,
     updated_at = NOW()
 WHERE id = $3
-RETURNING id, created_at, updated_at, email, password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, password, is_chirpy_red, handle, display_name, bio, location, website, avatar_id, email_verified_at
 `
 
 type UpdateUserParams struct {
@@ -36,6 +37,13 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.Password,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarID,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -10,20 +10,21 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (created_at, updated_at, email, password)
+INSERT INTO users (created_at, updated_at, email, password, handle)
 VALUES (
-    NOW(), NOW(), $1, $2
+    NOW(), NOW(), $1, $2, $3
 )
-RETURNING id, created_at, updated_at, email, password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, password, is_chirpy_red, handle, display_name, bio, location, website, avatar_id, email_verified_at
 `
 
 type CreateUserParams struct {
 	Email    string
 	Password string
+	Handle   string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.Password)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.Password, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -32,6 +33,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.Password,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarID,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,155 @@
+package entitlements
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// FreePlan is the plan of everyone without a Chirpy Red subscription, and
+// the fallback for plan names the config doesn't know.
+const FreePlan = "free"
+
+// Limits is what a plan allows. Zero means no limit wherever a field says so.
+type Limits struct {
+	MaxChirpLength   int `json:"max_chirp_length"`
+	MaxMediaPerChirp int `json:"max_media_per_chirp"`
+	// How long after posting a chirp can still be edited, zero for forever.
+	EditWindow Duration `json:"edit_window"`
+	// Authenticated API requests per user and minute, zero for unlimited.
+	RequestsPerMinute int `json:"requests_per_minute"`
+	// How many chirps may wait to be published at once, zero for unlimited.
+	// Nothing schedules chirps yet, so unlike the others a plan may leave
+	// this limit out; it becomes required once scheduling exists.
+	MaxScheduledChirps int `json:"max_scheduled_chirps"`
+}
+
+// limitsConfig is Limits as the config file spells it. Every field is a
+// pointer so a limit left out can be told apart from one set to zero.
+// MaxScheduledChirps is the exception, see Limits.
+type limitsConfig struct {
+	MaxChirpLength     *int      `json:"max_chirp_length"`
+	MaxMediaPerChirp   *int      `json:"max_media_per_chirp"`
+	EditWindow         *Duration `json:"edit_window"`
+	RequestsPerMinute  *int      `json:"requests_per_minute"`
+	MaxScheduledChirps int       `json:"max_scheduled_chirps"`
+}
+
+// Plans maps plan names, as Polka reports them, to their limits.
+type Plans map[string]Limits
+
+// Duration is a time.Duration written like "15m" in the config file.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Default is used when no plans file is configured.
+func Default() Plans {
+	return Plans{
+		FreePlan: {
+			MaxChirpLength:     140,
+			MaxMediaPerChirp:   4,
+			EditWindow:         Duration(time.Hour),
+			RequestsPerMinute:  60,
+			MaxScheduledChirps: 5,
+		},
+		"red": {
+			MaxChirpLength:     1000,
+			MaxMediaPerChirp:   4,
+			RequestsPerMinute:  600,
+			MaxScheduledChirps: 100,
+		},
+	}
+}
+
+// Load reads plans from a JSON file shaped like
+//
+//	{"free": {"max_chirp_length": 140, "max_media_per_chirp": 4, "edit_window": "1h", "requests_per_minute": 60, "max_scheduled_chirps": 5}, "red": {...}}
+//
+// Every plan lists all of its limits, max_scheduled_chirps aside, and there
+// has to be a free plan.
+func Load(path string) (Plans, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]limitsConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	plans := make(Plans, len(config))
+	for name, c := range config {
+		limits, err := c.limits()
+		if err != nil {
+			return nil, fmt.Errorf("%s: plan %s: %w", path, name, err)
+		}
+		plans[name] = limits
+	}
+	if err := plans.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plans, nil
+}
+
+// limits refuses a plan that leaves a limit out: a forgotten limit silently
+// becoming zero would lift it, or block chirping altogether.
+func (c limitsConfig) limits() (Limits, error) {
+	missing := func(field string) error { return fmt.Errorf("%s is missing", field) }
+	switch {
+	case c.MaxChirpLength == nil:
+		return Limits{}, missing("max_chirp_length")
+	case c.MaxMediaPerChirp == nil:
+		return Limits{}, missing("max_media_per_chirp")
+	case c.EditWindow == nil:
+		return Limits{}, missing("edit_window")
+	case c.RequestsPerMinute == nil:
+		return Limits{}, missing("requests_per_minute")
+	}
+	return Limits{
+		MaxChirpLength:     *c.MaxChirpLength,
+		MaxMediaPerChirp:   *c.MaxMediaPerChirp,
+		EditWindow:         *c.EditWindow,
+		RequestsPerMinute:  *c.RequestsPerMinute,
+		MaxScheduledChirps: c.MaxScheduledChirps,
+	}, nil
+}
+
+func (p Plans) validate() error {
+	if _, ok := p[FreePlan]; !ok {
+		return errors.New("no free plan")
+	}
+	for name, limits := range p {
+		if limits.MaxChirpLength < 1 {
+			return fmt.Errorf("plan %s: max_chirp_length must be at least 1", name)
+		}
+		if limits.MaxMediaPerChirp < 0 || limits.EditWindow < 0 || limits.RequestsPerMinute < 0 || limits.MaxScheduledChirps < 0 {
+			return fmt.Errorf("plan %s: limits can't be negative", name)
+		}
+	}
+	return nil
+}
+
+// For returns the limits of plan, or the free plan's when plan is unknown.
+func (p Plans) For(plan string) Limits {
+	if limits, ok := p[plan]; ok {
+		return limits
+	}
+	return p[FreePlan]
+}
@@ -0,0 +1,63 @@
+package entitlements
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// plan renders a plan with every limit set, for configs to build on.
+func plan(chirpLength, requestsPerMinute int, editWindow string) string {
+	return fmt.Sprintf(`{"max_chirp_length": %d, "max_media_per_chirp": 4, "edit_window": %q, "requests_per_minute": %d, "max_scheduled_chirps": 5}`, chirpLength, editWindow, requestsPerMinute)
+}
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{name: "valid", config: `{"free": ` + plan(140, 60, "15m") + `, "red": ` + plan(1000, 0, "0s") + `}`},
+		{name: "no free plan", config: `{"red": ` + plan(1000, 0, "0s") + `}`, wantErr: true},
+		{name: "missing limit", config: `{"free": {"max_chirp_length": 140, "max_media_per_chirp": 4, "edit_window": "15m"}}`, wantErr: true},
+		{name: "no scheduled chirps limit", config: `{"free": {"max_chirp_length": 140, "max_media_per_chirp": 4, "edit_window": "15m", "requests_per_minute": 60}}`},
+		{name: "zero chirp length", config: `{"free": ` + plan(0, 60, "15m") + `}`, wantErr: true},
+		{name: "negative limit", config: `{"free": ` + plan(140, -1, "15m") + `}`, wantErr: true},
+		{name: "bad duration", config: `{"free": ` + plan(140, 60, "soon") + `}`, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plans.json")
+			if err := os.WriteFile(path, []byte(c.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("expected error: %v got: %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPlansFor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plans.json")
+	config := `{"free": ` + plan(140, 60, "15m") + `, "red": ` + plan(1000, 600, "0s") + `}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	plans, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plans.For("red").MaxChirpLength; got != 1000 {
+		t.Fatalf("expected red chirp length 1000 got: %d", got)
+	}
+	if got := time.Duration(plans.For(FreePlan).EditWindow); got != 15*time.Minute {
+		t.Fatalf("expected free edit window 15m got: %v", got)
+	}
+	if got := plans.For("platinum").MaxChirpLength; got != 140 {
+		t.Fatalf("expected unknown plan to fall back to free, got chirp length: %d", got)
+	}
+}
@@ -0,0 +1,174 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Uploads bigger than this, once decoded, are refused before we allocate
+// them, so a tiny file can't claim gigabytes of memory.
+const maxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+)
+
+// Thumbnail sizes, bounded by their longest side in pixels.
+var ThumbnailSizes = []struct {
+	Name   string
+	MaxDim int
+}{
+	{Name: "small", MaxDim: 150},
+	{Name: "medium", MaxDim: 480},
+	{Name: "large", MaxDim: 1024},
+}
+
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+type Processed struct {
+	ContentType string
+	Width       int
+	Height      int
+	Original    []byte
+	Thumbnails  []Variant
+}
+
+// Process checks that data is an image we accept, judging by its content
+// rather than what the client claims, and re-encodes it. Re-encoding drops
+// every metadata block (EXIF, GPS, comments) the original carried, so a
+// JPEG's EXIF orientation is applied to the pixels first. GIFs come out as
+// a still PNG of their first frame.
+func Process(data []byte) (*Processed, error) {
+	sniffed := http.DetectContentType(data)
+	var decode func([]byte) (image.Image, error)
+	switch sniffed {
+	case "image/jpeg":
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+	case "image/png":
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+	case "image/gif":
+		decode = func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) }
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	img, err := decode(data)
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	contentType := "image/png"
+	if sniffed == "image/jpeg" {
+		contentType = "image/jpeg"
+	}
+	src := toRGBA(img)
+	if sniffed == "image/jpeg" {
+		src = orient(src, jpegOrientation(data))
+	}
+	original, err := encode(src, contentType)
+	if err != nil {
+		return nil, err
+	}
+	processed := &Processed{
+		ContentType: contentType,
+		Width:       src.Bounds().Dx(),
+		Height:      src.Bounds().Dy(),
+		Original:    original,
+	}
+	for _, size := range ThumbnailSizes {
+		thumb := resize(src, size.MaxDim)
+		data, err := encode(thumb, contentType)
+		if err != nil {
+			return nil, err
+		}
+		processed.Thumbnails = append(processed.Thumbnails, Variant{
+			Name:   size.Name,
+			Width:  thumb.Bounds().Dx(),
+			Height: thumb.Bounds().Dy(),
+			Data:   data,
+		})
+	}
+	return processed, nil
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// resize scales src down so its longest side is at most maxDim, averaging
+// every source pixel that falls into a destination pixel. Images that
+// already fit are returned untouched; we never upscale.
+func resize(src *image.RGBA, maxDim int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= maxDim && h <= maxDim {
+		return src
+	}
+	dw, dh := maxDim, maxDim
+	if w > h {
+		dh = max(1, h*maxDim/w)
+	} else {
+		dw = max(1, w*maxDim/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0, sy1 := y*h/dh, (y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			sx0, sx1 := x*w/dw, (x+1)*w/dw
+			var sum [4]int
+			n := 0
+			for sy := sy0; sy < sy1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					sum[0] += int(p[0])
+					sum[1] += int(p[1])
+					sum[2] += int(p[2])
+					sum[3] += int(p[3])
+					n++
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			for i := range d {
+				d[i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return dst
+}
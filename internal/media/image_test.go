@@ -0,0 +1,156 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func makeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg encode error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessStripsExif(t *testing.T) {
+	data := makeJPEG(t, 64, 32)
+	//splice an APP1 EXIF segment right after the SOI marker
+	payload := append([]byte("Exif\x00\x00"), []byte("GPS 51.5N 0.12W")...)
+	segment := append([]byte{0xFF, 0xE1, 0, byte(len(payload) + 2)}, payload...)
+	withExif := append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+
+	processed, err := Process(withExif)
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+	if processed.ContentType != "image/jpeg" {
+		t.Errorf("expected image/jpeg got: %s", processed.ContentType)
+	}
+	if bytes.Contains(processed.Original, []byte("Exif")) || bytes.Contains(processed.Original, []byte("GPS")) {
+		t.Errorf("EXIF metadata survived processing")
+	}
+}
+
+// withOrientation splices an APP1 EXIF segment holding just an orientation
+// tag right after the SOI marker of a JPEG.
+func withOrientation(data []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian, first IFD at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, // orientation, SHORT
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1, 0, byte(len(payload) + 2)}, payload...)
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	//a 64x32 photo taken with the camera held upright is shown 32x64
+	cases := []struct {
+		orientation   byte
+		width, height int
+	}{
+		{orientation: 1, width: 64, height: 32},
+		{orientation: 3, width: 64, height: 32},
+		{orientation: 6, width: 32, height: 64},
+		{orientation: 8, width: 32, height: 64},
+	}
+	for _, c := range cases {
+		processed, err := Process(withOrientation(makeJPEG(t, 64, 32), c.orientation))
+		if err != nil {
+			t.Fatalf("Process error: %v", err)
+		}
+		if processed.Width != c.width || processed.Height != c.height {
+			t.Errorf("orientation %d: expected %dx%d got: %dx%d", c.orientation, c.width, c.height, processed.Width, processed.Height)
+		}
+		thumb := processed.Thumbnails[0]
+		if (thumb.Width > thumb.Height) != (c.width > c.height) {
+			t.Errorf("orientation %d: thumbnail %dx%d isn't turned like the original", c.orientation, thumb.Width, thumb.Height)
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	//2x1 image: red on the left, blue on the right
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	//turned a quarter counterclockwise, so turn it clockwise: red on top
+	got := orient(src, 6)
+	if got.Bounds().Dx() != 1 || got.Bounds().Dy() != 2 {
+		t.Fatalf("expected 1x2 got: %v", got.Bounds())
+	}
+	if got.RGBAAt(0, 0) != red || got.RGBAAt(0, 1) != blue {
+		t.Errorf("orientation 6: expected red above blue got: %v above %v", got.RGBAAt(0, 0), got.RGBAAt(0, 1))
+	}
+	//turned a quarter clockwise, so turn it back: blue on top
+	got = orient(src, 8)
+	if got.RGBAAt(0, 0) != blue || got.RGBAAt(0, 1) != red {
+		t.Errorf("orientation 8: expected blue above red got: %v above %v", got.RGBAAt(0, 0), got.RGBAAt(0, 1))
+	}
+	//mirrored: blue on the left
+	got = orient(src, 2)
+	if got.RGBAAt(0, 0) != blue || got.RGBAAt(1, 0) != red {
+		t.Errorf("orientation 2: expected blue left of red got: %v left of %v", got.RGBAAt(0, 0), got.RGBAAt(1, 0))
+	}
+}
+
+func TestProcessThumbnails(t *testing.T) {
+	processed, err := Process(makeJPEG(t, 2000, 1000))
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+	if processed.Width != 2000 || processed.Height != 1000 {
+		t.Errorf("expected 2000x1000 original got: %dx%d", processed.Width, processed.Height)
+	}
+	if len(processed.Thumbnails) != len(ThumbnailSizes) {
+		t.Fatalf("expected %d thumbnails got: %d", len(ThumbnailSizes), len(processed.Thumbnails))
+	}
+	for i, thumb := range processed.Thumbnails {
+		want := ThumbnailSizes[i].MaxDim
+		if thumb.Width != want || thumb.Height != want/2 {
+			t.Errorf("%s: expected %dx%d got: %dx%d", thumb.Name, want, want/2, thumb.Width, thumb.Height)
+		}
+	}
+
+	//small images are never upscaled
+	processed, err = Process(makeJPEG(t, 100, 50))
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+	for _, thumb := range processed.Thumbnails {
+		if thumb.Width != 100 || thumb.Height != 50 {
+			t.Errorf("%s: expected 100x50 got: %dx%d", thumb.Name, thumb.Width, thumb.Height)
+		}
+	}
+}
+
+func TestProcessRejectsNonImages(t *testing.T) {
+	cases := map[string][]byte{
+		"plain text": []byte("definitely not an image"),
+		"html":       []byte("<html><body>hi</body></html>"),
+		"truncated":  makeJPEG(t, 16, 16)[:20],
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Process(data); !errors.Is(err, ErrUnsupportedType) {
+				t.Errorf("expected ErrUnsupportedType got: %v", err)
+			}
+		})
+	}
+}
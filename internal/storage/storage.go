@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps uploaded blobs. Keys are slash separated paths such as
+// "media/<id>/small" and are chosen by the caller.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Delete(ctx context.Context, key string) error
+	// URL is where clients can fetch the blob from.
+	URL(key string) string
+}
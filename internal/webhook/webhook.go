@@ -0,0 +1,51 @@
+// Package webhook holds the rules for sending webhooks to URLs our users
+// give us: which URLs are allowed, and when a failed delivery is retried or
+// its endpoint given up on.
+package webhook
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// A delivery is tried this many times, waiting RetryDelay after the first
+	// failure and twice as long after each one that follows.
+	MaxAttempts = 10
+	RetryDelay  = time.Minute
+	// An endpoint is disabled after failing this many attempts in a row.
+	FailureLimit = 25
+	// Timeout bounds a single attempt, connecting and reading included.
+	Timeout = 10 * time.Second
+)
+
+// NextRetry returns how long to wait before trying a delivery again after
+// its attempts-th attempt failed, and false once it has had all of them.
+func NextRetry(attempts int32) (time.Duration, bool) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts >= MaxAttempts {
+		return 0, false
+	}
+	return RetryDelay * time.Duration(math.Pow(2, float64(attempts-1))), true
+}
+
+// ShouldDisable reports whether an endpoint that failed consecutiveFailures
+// attempts in a row should stop getting deliveries.
+func ShouldDisable(consecutiveFailures int32) bool {
+	return consecutiveFailures >= FailureLimit
+}
+
+// Lease is how long a worker keeps the deliveries it claimed in one batch:
+// long enough for every one of them to time out, plus a margin.
+func Lease(batch int) time.Duration {
+	return time.Duration(batch+1) * Timeout
+}
+
+// LeaseHeld reports whether a worker that claimed a batch of the given size
+// at claimedAt can still start an attempt at now, and have it finish before
+// the lease runs out and another worker may claim the same delivery.
+func LeaseHeld(batch int, claimedAt, now time.Time) bool {
+	return !now.Add(Timeout).After(claimedAt.Add(Lease(batch)))
+}
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestNextRetry(t *testing.T) {
+	cases := []struct {
+		name      string
+		attempts  int32
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{name: "first failure", attempts: 1, wantDelay: time.Minute, wantRetry: true},
+		{name: "second failure", attempts: 2, wantDelay: 2 * time.Minute, wantRetry: true},
+		{name: "doubles every time", attempts: 5, wantDelay: 16 * time.Minute, wantRetry: true},
+		{name: "last retry", attempts: MaxAttempts - 1, wantDelay: 256 * time.Minute, wantRetry: true},
+		{name: "out of attempts", attempts: MaxAttempts, wantRetry: false},
+		{name: "past the limit", attempts: MaxAttempts + 3, wantRetry: false},
+		{name: "no attempts counts as one", attempts: 0, wantDelay: time.Minute, wantRetry: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			delay, retry := NextRetry(c.attempts)
+			if retry != c.wantRetry {
+				t.Fatalf("expected retry: %v got: %v", c.wantRetry, retry)
+			}
+			if retry && delay != c.wantDelay {
+				t.Errorf("expected delay: %v got: %v", c.wantDelay, delay)
+			}
+		})
+	}
+}
+
+func TestShouldDisable(t *testing.T) {
+	cases := []struct {
+		failures int32
+		want     bool
+	}{
+		{failures: 0, want: false},
+		{failures: 1, want: false},
+		{failures: FailureLimit - 1, want: false},
+		{failures: FailureLimit, want: true},
+		{failures: FailureLimit + 1, want: true},
+	}
+	for _, c := range cases {
+		if got := ShouldDisable(c.failures); got != c.want {
+			t.Errorf("failures: %d expected: %v got: %v", c.failures, c.want, got)
+		}
+	}
+}
+
+func TestLeaseHeld(t *testing.T) {
+	claimedAt := time.Now()
+	lease := Lease(20)
+	if lease < 20*Timeout {
+		t.Fatalf("lease %v can't fit 20 timeouts of %v", lease, Timeout)
+	}
+	cases := []struct {
+		name  string
+		after time.Duration
+		want  bool
+	}{
+		{name: "just claimed", after: 0, want: true},
+		{name: "halfway", after: lease / 2, want: true},
+		{name: "room for one more attempt", after: lease - Timeout, want: true},
+		{name: "attempt would outlast the lease", after: lease - Timeout + time.Second, want: false},
+		{name: "lease over", after: lease + time.Minute, want: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := LeaseHeld(20, claimedAt, claimedAt.Add(c.after)); got != c.want {
+				t.Errorf("expected: %v got: %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "224.0.0.1", want: false},
+		{addr: "ff02::1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "::", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "::ffff:10.0.0.1", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "100.127.255.254", want: false},
+		{addr: "100.128.0.1", want: true},
+		{addr: "0.1.2.3", want: false},
+		{addr: "198.18.0.1", want: false},
+		{addr: "198.19.255.254", want: false},
+		{addr: "198.20.0.1", want: true},
+		{addr: "192.0.2.10", want: false},
+		{addr: "240.0.0.1", want: false},
+		{addr: "255.255.255.255", want: false},
+		{addr: "64:ff9b::7f00:1", want: false},
+		{addr: "64:ff9b::a9fe:a9fe", want: false},
+		{addr: "2002:7f00:1::1", want: false},
+		{addr: "2001:db8::1", want: false},
+		{addr: "2001::1", want: false},
+	}
+	for _, c := range cases {
+		if got := PublicAddr(netip.MustParseAddr(c.addr)); got != c.want {
+			t.Errorf("addr: %s expected: %v got: %v", c.addr, c.want, got)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	cases := []struct {
+		name      string
+		url       string
+		allowHTTP bool
+		wantErr   error
+	}{
+		{name: "public https", url: "https://93.184.216.34/hooks"},
+		{name: "public http allowed", url: "http://93.184.216.34/hooks", allowHTTP: true},
+		{name: "http not allowed", url: "http://93.184.216.34/hooks", wantErr: errAny},
+		{name: "other scheme", url: "ftp://93.184.216.34/hooks", allowHTTP: true, wantErr: errAny},
+		{name: "relative", url: "/hooks", wantErr: errAny},
+		{name: "loopback", url: "https://127.0.0.1/hooks", wantErr: ErrForbiddenAddress},
+		{name: "loopback over http", url: "http://127.0.0.1:8080/hooks", allowHTTP: true, wantErr: ErrForbiddenAddress},
+		{name: "ipv6 loopback", url: "https://[::1]/hooks", wantErr: ErrForbiddenAddress},
+		{name: "private", url: "https://10.0.0.5/hooks", wantErr: ErrForbiddenAddress},
+		{name: "cloud metadata", url: "https://169.254.169.254/latest/meta-data", wantErr: ErrForbiddenAddress},
+		{name: "unspecified", url: "https://0.0.0.0/hooks", wantErr: ErrForbiddenAddress},
+		{name: "name of a loopback", url: "https://localhost/hooks", wantErr: ErrForbiddenAddress},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateURL(context.Background(), c.url, c.allowHTTP)
+			switch {
+			case c.wantErr == nil && err != nil:
+				t.Fatalf("expected no error got: %v", err)
+			case c.wantErr == errAny && err == nil:
+				t.Fatalf("expected an error")
+			case c.wantErr != nil && c.wantErr != errAny && !errors.Is(err, c.wantErr):
+				t.Fatalf("expected error: %v got: %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+// errAny stands for any error in TestValidateURL.
+var errAny = errors.New("any error")
+
+func TestNewClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	//the url was fine when saved, but now leads to this machine
+	_, err := NewClient().Post(server.URL, "application/json", nil)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("expected error: %v got: %v", ErrForbiddenAddress, err)
+	}
+}
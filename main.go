@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/Israel-Andrade-P/Chirpy.git/api"
 	"github.com/Israel-Andrade-P/Chirpy.git/internal/database"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/entitlements"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/mailer"
+	"github.com/Israel-Andrade-P/Chirpy.git/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -21,14 +27,85 @@ func main() {
 	dbUrl := os.Getenv("DB_URL")
 	platform := os.Getenv("PLATFORM")
 	secret := os.Getenv("JWT_SECRET")
-	polkaKey := os.Getenv("POLKA_KEY")
+	var polkaSecrets []string
+	for _, name := range []string{"POLKA_WEBHOOK_SECRET", "POLKA_WEBHOOK_SECRET_PREVIOUS"} {
+		if s := os.Getenv(name); s != "" {
+			polkaSecrets = append(polkaSecrets, s)
+		}
+	}
+	adminKey := os.Getenv("ADMIN_KEY")
+	baseUrl := os.Getenv("BASE_URL")
+	if baseUrl == "" {
+		baseUrl = "http://localhost:8080"
+	}
+	requireVerifiedEmail := os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true"
 	db, err := sql.Open("postgres", dbUrl)
 	if err != nil {
 		log.Fatalf("ERROR >> %v", err)
 	}
 	dbQueries := database.New(db)
 
-	apicfg := &api.Apiconfig{DbQueries: dbQueries, Platform: platform, Secret: secret, Expiration: 60, PolkaKey: polkaKey}
+	keys, err := api.LoadKeySet(context.Background(), dbQueries, secret)
+	if err != nil {
+		log.Fatalf("ERROR >> loading signing keys: %v", err)
+	}
+
+	// Limits per plan come from PLANS_FILE, or the built in defaults.
+	plans := entitlements.Default()
+	if plansFile := os.Getenv("PLANS_FILE"); plansFile != "" {
+		plans, err = entitlements.Load(plansFile)
+		if err != nil {
+			log.Fatalf("ERROR >> loading plans: %v", err)
+		}
+	}
+
+	// Uploads live next to the other static files so the /app/ file server
+	// hands them out.
+	mediaStorage := storage.NewLocalStorage("uploads", "/app/uploads")
+
+	// Without SMTP settings, emails are dropped into MAIL_DIR for local
+	// development. The mails hold login tokens, so they must never end up
+	// under the /app/ file server, and outside dev SMTP is required.
+	var mail mailer.Mailer
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		mail = &mailer.SMTPMailer{
+			Host:     smtpHost,
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+	} else if platform == "dev" {
+		mailDir := os.Getenv("MAIL_DIR")
+		if mailDir == "" {
+			mailDir = filepath.Join(os.TempDir(), "chirpy-mail")
+		}
+		log.Printf("No SMTP_HOST set, writing emails to %s", mailDir)
+		mail = &mailer.FileMailer{Dir: mailDir, From: os.Getenv("MAIL_FROM")}
+	} else {
+		log.Fatalf("ERROR >> SMTP_HOST is required outside the dev platform")
+	}
+
+	apicfg := &api.Apiconfig{
+		DB:                   db,
+		DbQueries:            dbQueries,
+		Platform:             platform,
+		Secret:               secret,
+		Keys:                 keys,
+		AdminKey:             adminKey,
+		Expiration:           60,
+		PolkaSecrets:         polkaSecrets,
+		Storage:              mediaStorage,
+		Mailer:               mail,
+		BaseURL:              baseUrl,
+		RequireVerifiedEmail: requireVerifiedEmail,
+		Plans:                plans,
+	}
+	go apicfg.ReloadKeys(context.Background(), time.Minute)
+	go apicfg.ExpireSubscriptions(context.Background(), time.Minute)
+	go apicfg.DeliverWebhooks(context.Background(), 5*time.Second)
+	go apicfg.PruneMedia(context.Background(), time.Hour)
+
 	mux := http.NewServeMux()
 
 	fileServer := http.StripPrefix("/app/", http.FileServer(http.Dir(".")))
@@ -39,15 +116,57 @@ func main() {
 	mux.HandleFunc("GET /admin/healthz", api.Readiness)
 	mux.HandleFunc("GET /admin/metrics", apicfg.HandlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apicfg.DeleteAllUsers)
+	mux.HandleFunc("POST /admin/keys/rotate", apicfg.RotateSigningKey)
+	mux.HandleFunc("GET /admin/webhooks/events", apicfg.GetWebhookEvents)
+	mux.HandleFunc("POST /admin/webhooks/events/{eventID}/replay", apicfg.ReplayWebhookEvent)
+	mux.HandleFunc("GET /.well-known/jwks.json", apicfg.GetJWKS)
 	mux.HandleFunc("POST /api/users", apicfg.RegisterUser)
 	mux.HandleFunc("PUT /api/users", apicfg.UpdateUser)
+	mux.HandleFunc("POST /api/users/verify", apicfg.VerifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", apicfg.ResendVerification)
+	mux.HandleFunc("PATCH /api/users/me", apicfg.UpdateProfile)
+	mux.HandleFunc("POST /api/users/me/avatar", apicfg.UploadAvatar)
+	mux.HandleFunc("POST /api/users/me/2fa/totp", apicfg.EnrollTOTP)
+	mux.HandleFunc("POST /api/users/me/2fa/totp/confirm", apicfg.ConfirmTOTP)
+	mux.HandleFunc("DELETE /api/users/me/2fa/totp", apicfg.DisableTOTP)
+	mux.HandleFunc("GET /api/users/{handle}", apicfg.GetProfile)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apicfg.FollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apicfg.UnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apicfg.GetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apicfg.GetFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/likes", apicfg.GetUserLikes)
+	mux.HandleFunc("GET /api/users/{userID}/mentions", apicfg.GetUserMentions)
 	mux.HandleFunc("POST /api/login", apicfg.Login)
+	mux.HandleFunc("POST /api/login/2fa", apicfg.LoginTwoFactor)
+	mux.HandleFunc("POST /api/password/forgot", apicfg.ForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", apicfg.ResetPassword)
+	mux.HandleFunc("POST /api/media", apicfg.UploadMedia)
 	mux.HandleFunc("POST /api/chirps", apicfg.SaveChirp)
 	mux.HandleFunc("GET /api/chirps", apicfg.GetChirps)
+	mux.HandleFunc("GET /api/chirps/search", apicfg.SearchChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apicfg.GetChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apicfg.UpdateChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apicfg.DeleteChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apicfg.GetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apicfg.GetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", apicfg.LikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apicfg.UnlikeChirp)
+	mux.HandleFunc("GET /api/timeline", apicfg.GetTimeline)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apicfg.GetHashtagChirps)
+	mux.HandleFunc("GET /api/trends", apicfg.GetTrends)
 	mux.HandleFunc("POST /api/refresh", apicfg.Refresh)
 	mux.HandleFunc("POST /api/revoke", apicfg.RevokeToken)
+	mux.HandleFunc("GET /api/sessions", apicfg.GetSessions)
+	mux.HandleFunc("DELETE /api/sessions/{id}", apicfg.RevokeSession)
+	mux.HandleFunc("POST /api/sessions/revoke-all", apicfg.RevokeOtherSessions)
+	mux.HandleFunc("POST /api/tokens", apicfg.CreateToken)
+	mux.HandleFunc("GET /api/tokens", apicfg.GetTokens)
+	mux.HandleFunc("DELETE /api/tokens/{tokenID}", apicfg.RevokeAccessToken)
+	mux.HandleFunc("POST /api/webhooks", apicfg.CreateWebhookEndpoint)
+	mux.HandleFunc("GET /api/webhooks", apicfg.GetWebhookEndpoints)
+	mux.HandleFunc("DELETE /api/webhooks/{webhookID}", apicfg.DeleteWebhookEndpoint)
+	mux.HandleFunc("POST /api/webhooks/{webhookID}/enable", apicfg.EnableWebhookEndpoint)
+	mux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", apicfg.GetWebhookDeliveries)
 	mux.HandleFunc("POST /api/polka/webhooks", apicfg.UpdateChirpRedStatus)
 
 	port := "8080"
@@ -1,2 +0,0 @@
--- name: GetAllChirps :many
-SELECT * FROM chirps ORDER BY created_at ASC;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- name: CountChirpReplies :one
+SELECT COUNT(*) FROM chirps WHERE parent_id=$1;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT c.id, c.body, c.created_at, c.updated_at, c.user_id, c.edited_at, c.parent_id, c.deleted_at, 1 AS depth
+    FROM chirps c
+    WHERE c.id = (SELECT p.parent_id FROM chirps p WHERE p.id = $1)
+    UNION ALL
+    SELECT c.id, c.body, c.created_at, c.updated_at, c.user_id, c.edited_at, c.parent_id, c.deleted_at, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT a.id, a.body, a.created_at, a.updated_at, a.user_id, a.edited_at, a.parent_id, a.deleted_at,
+       (SELECT COUNT(*) FROM chirps r WHERE r.parent_id = a.id AND r.deleted_at IS NULL) AS reply_count
+FROM ancestors a
+ORDER BY a.depth DESC;
+
+-- name: CountVisibleChirpReplies :one
+SELECT COUNT(*) FROM chirps WHERE parent_id = $1 AND deleted_at IS NULL;
+
+-- name: GetChirpDescendants :many
+-- One page of direct replies, oldest first, each with at most nested_limit
+-- replies of its own per level down to max_depth. Deeper or further replies
+-- are only counted, clients open their thread to see them.
+WITH RECURSIVE descendants AS (
+    (
+        SELECT c.id, c.body, c.created_at, c.updated_at, c.user_id, c.edited_at, c.parent_id, c.deleted_at, 1 AS depth
+        FROM chirps c
+        WHERE c.parent_id = sqlc.arg('parent_id')
+          AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+               OR (c.created_at, c.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+        ORDER BY c.created_at ASC, c.id ASC
+        LIMIT sqlc.arg('limit')
+    )
+    UNION ALL
+    SELECT c.id, c.body, c.created_at, c.updated_at, c.user_id, c.edited_at, c.parent_id, c.deleted_at, d.depth + 1
+    FROM descendants d
+    CROSS JOIN LATERAL (
+        SELECT r.* FROM chirps r
+        WHERE r.parent_id = d.id
+        ORDER BY r.created_at ASC, r.id ASC
+        LIMIT sqlc.arg('nested_limit')
+    ) c
+    WHERE d.depth < sqlc.arg('max_depth')::integer
+)
+SELECT d.id, d.body, d.created_at, d.updated_at, d.user_id, d.edited_at, d.parent_id, d.deleted_at, d.depth,
+       (SELECT COUNT(*) FROM chirps r WHERE r.parent_id = d.id AND r.deleted_at IS NULL) AS reply_count
+FROM descendants d
+ORDER BY d.depth ASC, d.created_at ASC, d.id ASC;
@@ -1,6 +1,6 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (body, created_at, updated_at, user_id)
+INSERT INTO chirps (body, created_at, updated_at, user_id, parent_id, repost_of_id)
 VALUES (
-    $1, NOW(), NOW(), $2
+    $1, NOW(), NOW(), $2, $3, $4
 )
 RETURNING *;
\ No newline at end of file
@@ -1,2 +1,7 @@
 -- name: GetChirp :one
-SELECT * FROM chirps WHERE id=$1;
\ No newline at end of file
+SELECT * FROM chirps WHERE id=$1;
+
+-- name: GetChirpForUpdate :one
+-- Locks the chirp until the transaction ends, so concurrent edits each see
+-- the version the other one left behind.
+SELECT * FROM chirps WHERE id = $1 FOR UPDATE;
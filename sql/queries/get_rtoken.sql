@@ -1,2 +1,2 @@
 -- name: GetRefreshToken :one
-SELECT * FROM refresh_tokens WHERE token=$1;
\ No newline at end of file
+SELECT * FROM refresh_tokens WHERE id=$1;
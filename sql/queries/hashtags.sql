@@ -0,0 +1,58 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (tag, created_at)
+VALUES (
+    $1, NOW()
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING id;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES (
+    $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearChirpHashtags :exec
+DELETE FROM chirp_hashtags WHERE chirp_id = $1;
+
+-- name: PruneChirpHashtags :exec
+-- Unlinks the hashtags of a chirp that aren't among tags any more. The rest
+-- keep their link, dated when the tag was first used.
+DELETE FROM chirp_hashtags
+USING hashtags
+WHERE chirp_hashtags.chirp_id = sqlc.arg('chirp_id')
+  AND hashtags.id = chirp_hashtags.hashtag_id
+  AND NOT (hashtags.tag = ANY(sqlc.arg('tags')::text[]));
+
+-- name: ListHashtagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+  AND chirps.deleted_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingHashtags :many
+-- Velocity score: uses in the current window squared, over the uses in the
+-- window of the same length right before it. Busy tags that are also
+-- growing float to the top. The windows end at the database's NOW(), the
+-- clock created_at comes from.
+WITH counts AS (
+    SELECT hashtag_id,
+           COUNT(*) FILTER (WHERE created_at >= NOW() - sqlc.arg('window_seconds')::integer * INTERVAL '1 second') AS current_count,
+           COUNT(*) FILTER (WHERE created_at < NOW() - sqlc.arg('window_seconds')::integer * INTERVAL '1 second') AS previous_count
+    FROM chirp_hashtags
+    WHERE created_at >= NOW() - 2 * sqlc.arg('window_seconds')::integer * INTERVAL '1 second'
+    GROUP BY hashtag_id
+)
+SELECT hashtags.tag, counts.current_count, counts.previous_count,
+       (counts.current_count * counts.current_count)::float8 / (counts.previous_count + 1) AS score
+FROM counts
+JOIN hashtags ON hashtags.id = counts.hashtag_id
+WHERE counts.current_count > 0
+ORDER BY score DESC, counts.current_count DESC, hashtags.tag ASC
+LIMIT sqlc.arg('limit');
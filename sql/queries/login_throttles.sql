@@ -0,0 +1,36 @@
+-- name: ReserveLoginAttempt :one
+-- Counts an attempt as failed before it is checked, so concurrent guesses
+-- each get their own number. Failures older than the reset window don't
+-- count, the counter starts over. While the key is locked out nothing is
+-- counted and locked_seconds says how long the lockout has left.
+INSERT INTO login_throttles (scope, key, failures, last_failed_at)
+VALUES (
+    sqlc.arg('scope'), sqlc.arg('key'), 1, NOW()
+)
+ON CONFLICT (scope, key) DO UPDATE
+SET failures = CASE
+        WHEN login_throttles.locked_until > NOW() THEN login_throttles.failures
+        WHEN login_throttles.last_failed_at < sqlc.arg('reset_before')::timestamp THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failed_at = CASE
+        WHEN login_throttles.locked_until > NOW() THEN login_throttles.last_failed_at
+        ELSE NOW()
+    END
+RETURNING failures, GREATEST(EXTRACT(EPOCH FROM locked_until - NOW()), 0)::float8 AS locked_seconds;
+
+-- name: LockLogin :exec
+UPDATE login_throttles
+SET locked_until = NOW() + sqlc.arg('delay_ms')::bigint * INTERVAL '1 millisecond'
+WHERE scope = sqlc.arg('scope') AND key = sqlc.arg('key');
+
+-- name: ReleaseLoginAttempt :exec
+-- Takes back a reserved attempt that turned out to succeed, along with the
+-- lockout it set.
+UPDATE login_throttles
+SET failures = GREATEST(failures - 1, 0),
+    locked_until = NULL
+WHERE scope = $1 AND key = $2;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_throttles WHERE scope = $1 AND key = $2;
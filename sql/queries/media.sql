@@ -0,0 +1,50 @@
+-- name: CreateMedia :one
+INSERT INTO media (user_id, content_type, width, height, created_at)
+VALUES (
+    $1, $2, $3, $4, NOW()
+)
+RETURNING *;
+
+-- name: DeleteMedia :exec
+DELETE FROM media WHERE id = $1;
+
+-- name: GetUnattachedMedia :many
+SELECT * FROM media
+WHERE user_id = sqlc.arg('user_id')
+  AND id = ANY(sqlc.arg('ids')::uuid[])
+  AND id NOT IN (SELECT media_id FROM chirp_media);
+
+-- name: AttachChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES (
+    $1, $2, $3
+);
+
+-- name: GetChirpMedia :many
+SELECT chirp_media.chirp_id, media.id, media.content_type, media.width, media.height
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position;
+
+-- name: SetUserAvatar :one
+UPDATE users
+SET avatar_id = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: DeleteOrphanedMedia :many
+-- Removes media nobody uses: uploads never attached to a chirp and avatars
+-- that were replaced. Rows an upload is being attached to right now are
+-- locked by that insert and skipped.
+DELETE FROM media
+WHERE id IN (
+    SELECT m.id FROM media m
+    WHERE m.created_at < NOW() - sqlc.arg('min_age_ms')::bigint * INTERVAL '1 millisecond'
+      AND NOT EXISTS (SELECT 1 FROM chirp_media cm WHERE cm.media_id = m.id)
+      AND NOT EXISTS (SELECT 1 FROM users u WHERE u.avatar_id = m.id)
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id;
@@ -0,0 +1,28 @@
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users WHERE handle = ANY(sqlc.arg('handles')::text[]);
+
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset, created_at)
+VALUES (
+    $1, $2, $3, $4, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearChirpMentions :exec
+DELETE FROM chirp_mentions WHERE chirp_id = $1;
+
+-- name: GetChirpMentions :many
+SELECT chirp_mentions.chirp_id, chirp_mentions.user_id, users.handle, chirp_mentions.start_offset, chirp_mentions.end_offset
+FROM chirp_mentions
+JOIN users ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_mentions.chirp_id, chirp_mentions.start_offset;
+
+-- name: ListUserMentions :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND id IN (SELECT chirp_id FROM chirp_mentions WHERE chirp_mentions.user_id = sqlc.arg('user_id'))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
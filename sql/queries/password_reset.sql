@@ -0,0 +1,25 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+VALUES (
+    $1, $2, NOW(), $3
+);
+
+-- name: GetPasswordResetToken :one
+SELECT * FROM password_reset_tokens WHERE token_hash = $1;
+
+-- name: UsePasswordResetToken :execrows
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: InvalidatePasswordResetTokens :exec
+-- Older links stop working once one of them has been used.
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2,
+    updated_at = NOW()
+WHERE id = $1;
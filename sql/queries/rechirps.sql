@@ -0,0 +1,20 @@
+-- name: GetChirpsByIds :many
+SELECT * FROM chirps WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: GetChirpRepostStats :many
+SELECT repost_of_id::uuid AS chirp_id,
+       COUNT(*) FILTER (WHERE body = '' AND deleted_at IS NULL) AS rechirp_count,
+       COUNT(*) FILTER (WHERE body <> '' AND deleted_at IS NULL) AS quote_count
+FROM chirps
+WHERE repost_of_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+  AND deleted_at IS NULL
+GROUP BY repost_of_id;
+
+-- name: CountChirpQuotes :one
+-- Counts what points at the chirp and outlives DeleteRechirpsOf: quotes, and
+-- tombstones of quotes and rechirps, which a tombstone's blank body doesn't
+-- make plain rechirps.
+SELECT COUNT(*) FROM chirps WHERE repost_of_id=$1 AND (body <> '' OR deleted_at IS NOT NULL);
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps WHERE repost_of_id=$1 AND body = '' AND deleted_at IS NULL;
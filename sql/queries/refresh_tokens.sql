@@ -1,6 +1,21 @@
--- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (id, token_hash, created_at, updated_at, user_id, expires_at, family_id, user_agent, ip, last_used_at)
 VALUES (
-    $1, NOW(), NOW(), $2, $3
-)
-RETURNING token;
\ No newline at end of file
+    $1, $2, NOW(), NOW(), $3, $4, $5, $6, $7, NOW()
+);
+
+-- name: ClaimRefreshToken :execrows
+-- Revokes a token that is being exchanged for a new one. Zero rows means it
+-- was revoked already, either traded in or logged out.
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    rotated_at = NOW(),
+    updated_at = NOW(),
+    last_used_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,12 @@
+-- name: SearchChirps :many
+-- The snippet is HTML: the body is escaped before the matches are wrapped in
+-- <mark> tags, so it can be rendered as is.
+SELECT sqlc.embed(chirps),
+       ts_rank(chirps.search_vector, query) AS rank,
+       ts_headline('english', replace(replace(replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'), query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')::text AS snippet
+FROM chirps, to_tsquery('english', sqlc.arg('query')) query
+WHERE chirps.search_vector @@ query
+  AND chirps.deleted_at IS NULL
+  AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,16 @@
+-- name: ListSigningKeys :many
+-- Retired keys are only loaded while their tokens may still be around.
+SELECT * FROM signing_keys
+WHERE retired_at IS NULL OR retired_at > $1
+ORDER BY created_at DESC;
+
+-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (id, private_key, created_at)
+VALUES (
+    $1, $2, $3
+);
+
+-- name: RetireSigningKey :exec
+UPDATE signing_keys
+SET retired_at = $2
+WHERE id = $1;
@@ -0,0 +1,62 @@
+-- name: GetSubscription :one
+SELECT * FROM subscriptions WHERE user_id = $1;
+
+-- name: LockSubscription :one
+-- Held until the event is applied, so events about one member are applied
+-- one at a time and each sees when the last one was sent.
+SELECT last_event_at FROM subscriptions WHERE user_id = $1 FOR UPDATE;
+
+-- name: ActivateSubscription :exec
+-- Both a new membership and a renewal start a clean period.
+INSERT INTO subscriptions (user_id, plan, status, current_period_end, last_event_at, created_at, updated_at)
+VALUES (
+    $1, $2, 'active', $3, $4, NOW(), NOW()
+)
+ON CONFLICT (user_id) DO UPDATE
+SET plan = EXCLUDED.plan,
+    status = 'active',
+    current_period_end = EXCLUDED.current_period_end,
+    grace_period_end = NULL,
+    last_event_at = EXCLUDED.last_event_at,
+    updated_at = NOW();
+
+-- name: MarkSubscriptionPastDue :execrows
+-- Further failures don't extend the grace period the first one started.
+-- Period ends are kept in UTC, the columns having no time zone.
+UPDATE subscriptions
+SET status = 'past_due',
+    grace_period_end = COALESCE(grace_period_end, (NOW() AT TIME ZONE 'UTC') + sqlc.arg('grace_ms')::bigint * INTERVAL '1 millisecond'),
+    last_event_at = sqlc.arg('last_event_at'),
+    updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND status IN ('active', 'past_due');
+
+-- name: CancelSubscription :exec
+UPDATE subscriptions
+SET status = 'canceled', grace_period_end = NULL, last_event_at = $2, updated_at = NOW()
+WHERE user_id = $1;
+
+-- name: ExpireSubscriptions :execrows
+-- Ends every membership whose period, or grace period after a failed
+-- payment, is over and takes Chirpy Red away from its user.
+WITH expired AS (
+    UPDATE subscriptions
+    SET status = 'expired', updated_at = NOW()
+    WHERE status IN ('active', 'past_due')
+      AND COALESCE(grace_period_end, current_period_end) < NOW() AT TIME ZONE 'UTC'
+    RETURNING user_id
+)
+UPDATE users
+SET is_chirpy_red = false
+FROM expired
+WHERE users.id = expired.user_id;
+
+-- name: GetUserPlan :one
+-- The plan whose limits apply to a user: their subscription's while they
+-- have Chirpy Red, 'free' otherwise.
+SELECT (CASE
+        WHEN users.is_chirpy_red THEN COALESCE(subscriptions.plan, 'red')
+        ELSE 'free'
+    END)::text AS plan
+FROM users
+LEFT JOIN subscriptions ON subscriptions.user_id = users.id
+WHERE users.id = $1;
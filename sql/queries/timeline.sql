@@ -0,0 +1,9 @@
+-- name: ListTimelineChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+  AND (user_id = sqlc.arg('user_id')
+       OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id')))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
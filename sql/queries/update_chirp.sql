@@ -0,0 +1,7 @@
+-- name: UpdateChirp :one
+UPDATE chirps
+SET body = $1,
+    updated_at = NOW(),
+    edited_at = NOW()
+WHERE id = $2
+RETURNING *;
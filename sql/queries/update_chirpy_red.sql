@@ -1,4 +1,4 @@
--- name: UpdateChirpyRed :exec
+-- name: UpdateChirpyRed :execrows
 UPDATE users
-SET is_chirpy_red = true
-WHERE id = $1;
\ No newline at end of file
+SET is_chirpy_red = $2
+WHERE id = $1;
@@ -1,6 +1,7 @@
 -- name: UpdateUser :one
 UPDATE users
-SET email = $1,
+SET email_verified_at = CASE WHEN email = $1 THEN email_verified_at END,
+    email = $1,
     password = $2,
     updated_at = NOW()
 WHERE id = $3
@@ -0,0 +1,19 @@
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = COALESCE(sqlc.narg('handle'), handle),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    location = COALESCE(sqlc.narg('location'), location),
+    website = COALESCE(sqlc.narg('website'), website),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: GetUserProfileByHandle :one
+SELECT users.id, users.created_at, users.handle, users.display_name, users.bio,
+       users.location, users.website, users.is_chirpy_red, users.avatar_id,
+       (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.deleted_at IS NULL) AS chirp_count,
+       (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+       (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE users.handle = $1;
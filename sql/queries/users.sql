@@ -1,6 +1,6 @@
 -- name: CreateUser :one
-INSERT INTO users (created_at, updated_at, email, password)
+INSERT INTO users (created_at, updated_at, email, password, handle)
 VALUES (
-    NOW(), NOW(), $1, $2
+    NOW(), NOW(), $1, $2, $3
 )
 RETURNING *;
\ No newline at end of file
@@ -0,0 +1,52 @@
+-- name: EnqueueWebhookDeliveries :exec
+-- Queues an event for every enabled endpoint of the user that subscribed
+-- to it.
+INSERT INTO webhook_deliveries (endpoint_id, event_type, payload, status, next_attempt_at, created_at)
+SELECT id, sqlc.arg('event_type')::text, sqlc.arg('payload')::jsonb, 'pending', NOW(), NOW()
+FROM webhook_endpoints
+WHERE user_id = sqlc.arg('user_id')
+  AND disabled_at IS NULL
+  AND sqlc.arg('event_type')::text = ANY(events);
+
+-- name: ClaimWebhookDeliveries :many
+-- Leases due deliveries to one worker by pushing their next attempt out.
+-- If the worker dies the lease runs out and another one picks them up.
+UPDATE webhook_deliveries
+SET next_attempt_at = NOW() + sqlc.arg('lease_ms')::bigint * INTERVAL '1 millisecond'
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded',
+    attempts = attempts + 1,
+    last_attempt_at = NOW(),
+    response_status = $2,
+    error = NULL
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- A delivery with a retry delay stays pending, one without has failed for
+-- good.
+UPDATE webhook_deliveries
+SET status = CASE WHEN sqlc.narg('retry_ms')::bigint IS NULL THEN 'failed' ELSE 'pending' END,
+    attempts = attempts + 1,
+    last_attempt_at = NOW(),
+    next_attempt_at = COALESCE(NOW() + sqlc.narg('retry_ms')::bigint * INTERVAL '1 millisecond', next_attempt_at),
+    response_status = sqlc.narg('response_status'),
+    error = sqlc.arg('error')
+WHERE id = sqlc.arg('id');
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg('endpoint_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
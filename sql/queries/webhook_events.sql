@@ -0,0 +1,33 @@
+-- name: RecordWebhookEvent :exec
+-- Deliveries of an event we already have are dropped, the stored copy wins.
+INSERT INTO webhook_events (event_id, event_type, payload, sent_at, received_at, status)
+VALUES (
+    $1, $2, $3, $4, NOW(), 'pending'
+)
+ON CONFLICT (event_id) DO NOTHING;
+
+-- name: GetWebhookEvent :one
+SELECT * FROM webhook_events WHERE id = $1;
+
+-- name: LockWebhookEvent :one
+-- Held until the event is applied, so concurrent deliveries of the same
+-- event wait for each other instead of both applying it.
+SELECT * FROM webhook_events WHERE event_id = $1 FOR UPDATE;
+
+-- name: FinishWebhookEvent :exec
+UPDATE webhook_events
+SET status = $2, error = NULL, attempts = attempts + 1, processed_at = NOW()
+WHERE id = $1;
+
+-- name: FailWebhookEvent :exec
+UPDATE webhook_events
+SET status = 'failed', error = $2, attempts = attempts + 1
+WHERE id = $1;
+
+-- name: ListWebhookEvents :many
+SELECT * FROM webhook_events
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('cursor_received_at')::timestamp IS NULL
+       OR (received_at, id) < (sqlc.narg('cursor_received_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY received_at DESC, id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN parent_id UUID,
+ADD COLUMN deleted_at TIMESTAMP,
+ADD CONSTRAINT fk_chirps_parent_id FOREIGN KEY (parent_id) REFERENCES chirps (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_chirps_parent_id ON chirps (parent_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chirps_parent_id;
+
+ALTER TABLE chirps
+DROP CONSTRAINT fk_chirps_parent_id,
+DROP COLUMN deleted_at,
+DROP COLUMN parent_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL,
+    followee_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT chk_follows_not_self CHECK (follower_id <> followee_id),
+    CONSTRAINT fk_follows_follower_id FOREIGN KEY (follower_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_follows_followee_id FOREIGN KEY (followee_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_follows_followee_id ON follows (followee_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS follows;
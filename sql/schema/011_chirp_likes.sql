@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    user_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    CONSTRAINT uq_chirp_likes_user_chirp UNIQUE (user_id, chirp_id),
+    CONSTRAINT fk_chirp_likes_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_chirp_likes_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_chirp_likes_chirp_id ON chirp_likes (chirp_id);
+CREATE INDEX idx_chirp_likes_user_id_created_at ON chirp_likes (user_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_likes;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN repost_of_id UUID,
+ADD CONSTRAINT fk_chirps_repost_of_id FOREIGN KEY (repost_of_id) REFERENCES chirps (id) ON DELETE CASCADE;
+
+CREATE INDEX idx_chirps_repost_of_id ON chirps (repost_of_id);
+
+-- A plain rechirp has no body of its own and can only be done once per user.
+CREATE UNIQUE INDEX uq_chirps_rechirp ON chirps (user_id, repost_of_id)
+    WHERE repost_of_id IS NOT NULL AND body = '' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS uq_chirps_rechirp;
+DROP INDEX IF EXISTS idx_chirps_repost_of_id;
+
+ALTER TABLE chirps
+DROP CONSTRAINT fk_chirps_repost_of_id,
+DROP COLUMN repost_of_id;
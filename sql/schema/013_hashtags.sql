@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE hashtags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tag TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID NOT NULL,
+    hashtag_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, hashtag_id),
+    CONSTRAINT fk_chirp_hashtags_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE,
+    CONSTRAINT fk_chirp_hashtags_hashtag_id FOREIGN KEY (hashtag_id) REFERENCES hashtags (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_chirp_hashtags_hashtag_id ON chirp_hashtags (hashtag_id, created_at);
+CREATE INDEX idx_chirp_hashtags_created_at ON chirp_hashtags (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_hashtags;
+DROP TABLE IF EXISTS hashtags;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Existing accounts get a random placeholder handle they can change later.
+ALTER TABLE users
+ADD COLUMN handle TEXT NOT NULL UNIQUE DEFAULT ('user_' || substr(md5(random()::text), 1, 10));
+
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset),
+    CONSTRAINT fk_chirp_mentions_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE,
+    CONSTRAINT fk_chirp_mentions_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_chirp_mentions_user_id ON chirp_mentions (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_mentions;
+
+ALTER TABLE users
+DROP COLUMN handle;
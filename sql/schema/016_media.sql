@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE media (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    CONSTRAINT fk_media_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE chirp_media (
+    chirp_id UUID NOT NULL,
+    media_id UUID NOT NULL UNIQUE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, media_id),
+    CONSTRAINT fk_chirp_media_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE,
+    CONSTRAINT fk_chirp_media_media_id FOREIGN KEY (media_id) REFERENCES media (id) ON DELETE CASCADE
+);
+
+ALTER TABLE users
+ADD COLUMN avatar_id UUID,
+ADD CONSTRAINT fk_users_avatar_id FOREIGN KEY (avatar_id) REFERENCES media (id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE users
+DROP CONSTRAINT fk_users_avatar_id,
+DROP COLUMN avatar_id;
+
+DROP TABLE IF EXISTS chirp_media;
+DROP TABLE IF EXISTS media;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Refresh tokens are looked up by their first 16 hex characters and checked
+-- against a SHA-256 digest of the whole token, the raw token is never stored.
+-- Existing tokens are converted in place so nobody gets logged out.
+ALTER TABLE refresh_tokens
+ADD COLUMN id TEXT,
+ADD COLUMN token_hash TEXT;
+
+UPDATE refresh_tokens
+SET id = LEFT(token, 16),
+    token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens
+DROP CONSTRAINT refresh_tokens_pkey,
+DROP COLUMN token,
+ALTER COLUMN id SET NOT NULL,
+ALTER COLUMN token_hash SET NOT NULL,
+ADD PRIMARY KEY (id);
+
+-- +goose Down
+-- The raw tokens are gone, everybody has to log in again.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+DROP CONSTRAINT refresh_tokens_pkey,
+DROP COLUMN id,
+DROP COLUMN token_hash,
+ADD COLUMN token TEXT PRIMARY KEY;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE signing_keys (
+    id TEXT PRIMARY KEY,
+    -- Ed25519 seed, AES-GCM encrypted with a key derived from JWT_SECRET.
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    retired_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS signing_keys;
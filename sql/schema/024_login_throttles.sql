@@ -0,0 +1,15 @@
+-- +goose Up
+-- Failed login counters, per account (scope 'account', key the lowercased
+-- email, whether or not it exists), per client IP (scope 'ip') and per user
+-- for second factor codes (scope 'mfa').
+CREATE TABLE login_throttles (
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    failures INTEGER NOT NULL,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_throttles;
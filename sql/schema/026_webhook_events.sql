@@ -0,0 +1,20 @@
+-- +goose Up
+-- Every webhook Polka delivers, so retries of an event already applied are
+-- recognised and failed ones can be replayed. Status is one of 'pending',
+-- 'processed', 'failed' or 'ignored' (event types we don't handle).
+CREATE TABLE webhook_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id TEXT NOT NULL UNIQUE,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    received_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    processed_at TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_events_received_at ON webhook_events (received_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_events;
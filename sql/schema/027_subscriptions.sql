@@ -0,0 +1,27 @@
+-- +goose Up
+-- A user's Chirpy Red membership as Polka last reported it. Status is one of
+-- 'active', 'past_due' (a payment failed, the grace period is running),
+-- 'canceled' or 'expired'. users.is_chirpy_red follows it.
+CREATE TABLE subscriptions (
+    user_id UUID PRIMARY KEY,
+    plan TEXT NOT NULL,
+    status TEXT NOT NULL,
+    current_period_end TIMESTAMP NOT NULL,
+    grace_period_end TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT fk_subscriptions_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_subscriptions_expiry ON subscriptions (COALESCE(grace_period_end, current_period_end))
+WHERE status IN ('active', 'past_due');
+
+-- Members from before subscriptions were tracked get a month, Polka's next
+-- renewal fills in the real period.
+INSERT INTO subscriptions (user_id, plan, status, current_period_end, created_at, updated_at)
+SELECT id, 'red', 'active', (NOW() AT TIME ZONE 'UTC') + INTERVAL '1 month', NOW(), NOW()
+FROM users
+WHERE is_chirpy_red;
+
+-- +goose Down
+DROP TABLE IF EXISTS subscriptions;
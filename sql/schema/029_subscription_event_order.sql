@@ -0,0 +1,13 @@
+-- +goose Up
+-- Polka doesn't deliver events in order. Keep when each one was sent, and
+-- the newest one applied to a subscription, so a late event can't undo a
+-- newer one.
+ALTER TABLE webhook_events ADD COLUMN sent_at TIMESTAMP;
+UPDATE webhook_events SET sent_at = received_at;
+ALTER TABLE webhook_events ALTER COLUMN sent_at SET NOT NULL;
+
+ALTER TABLE subscriptions ADD COLUMN last_event_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS sent_at;
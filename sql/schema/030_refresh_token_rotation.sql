@@ -0,0 +1,8 @@
+-- +goose Up
+-- A refresh token is revoked both when it's traded for a new one and when
+-- its session is logged out. Only presenting a traded one again means it
+-- was stolen, so keep which of the two happened.
+ALTER TABLE refresh_tokens ADD COLUMN rotated_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS rotated_at;
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 64
+
+// ExtractHashtags returns the distinct, normalized #hashtags found in a
+// message, in the order they first appear.
+func ExtractHashtags(message string) []string {
+	var tags []string
+	runes := []rune(message)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' {
+			continue
+		}
+		// A # glued to a word, like in "c#", doesn't start a hashtag.
+		if i > 0 && isTagRune(runes[i-1]) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isTagRune(runes[j]) {
+			j++
+		}
+		if tag := NormalizeHashtag(string(runes[i+1 : j])); tag != "" && !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+		i = j - 1
+	}
+	return tags
+}
+
+// NormalizeHashtag lower-cases a tag and strips the leading #, so "#Go" and
+// "go" end up being the same hashtag. Invalid tags come back empty.
+func NormalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || len(tag) > maxHashtagLength {
+		return ""
+	}
+	onlyDigits := true
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return ""
+		}
+		if !unicode.IsDigit(r) {
+			onlyDigits = false
+		}
+	}
+	// "#1" is a number, not a topic.
+	if onlyDigits {
+		return ""
+	}
+	return tag
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,59 @@
+package utils
+
+import "strings"
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 15
+)
+
+// Mention is an @handle found in a message. Start and End are offsets in
+// characters (runes), End being exclusive, and cover the leading @.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// ExtractMentions returns every valid @handle in a message, in order.
+func ExtractMentions(message string) []Mention {
+	var mentions []Mention
+	runes := []rune(message)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' {
+			continue
+		}
+		// Skip the @ inside something like an email address.
+		if i > 0 && isHandleRune(runes[i-1]) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isHandleRune(runes[j]) {
+			j++
+		}
+		if handle := NormalizeHandle(string(runes[i+1 : j])); handle != "" {
+			mentions = append(mentions, Mention{Handle: handle, Start: i, End: j})
+		}
+		i = j - 1
+	}
+	return mentions
+}
+
+// NormalizeHandle lower-cases a handle and strips the leading @. Handles are
+// 3 to 15 letters, digits or underscores; anything else comes back empty.
+func NormalizeHandle(handle string) string {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return ""
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return ""
+		}
+	}
+	return handle
+}
+
+func isHandleRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// BuildTsQuery turns a user search string into a Postgres to_tsquery
+// expression. Quoted text becomes a phrase match, a trailing * makes a
+// prefix match and every other word has to be present.
+func BuildTsQuery(search string) (string, error) {
+	var terms []string
+	for i, chunk := range strings.Split(search, `"`) {
+		// Odd chunks sit between a pair of quotes.
+		if i%2 == 1 {
+			var words []string
+			for _, word := range strings.Fields(chunk) {
+				if w := sanitizeTerm(word); w != "" {
+					words = append(words, w)
+				}
+			}
+			if len(words) > 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+		for _, word := range strings.Fields(chunk) {
+			w := sanitizeTerm(word)
+			if w == "" {
+				continue
+			}
+			if strings.HasSuffix(word, "*") {
+				w += ":*"
+			}
+			terms = append(terms, w)
+		}
+	}
+	if len(terms) == 0 {
+		return "", errors.New("empty search query")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+// sanitizeTerm drops everything that would be tsquery syntax.
+func sanitizeTerm(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}